@@ -0,0 +1,265 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+var (
+	clientsMutex sync.Mutex
+	clients      []*Client
+
+	schemaAgreementInterval = 200 * time.Millisecond
+)
+
+// Client is returned by configureProvider and shared by all resources. It
+// lazily creates a single session which is reused across resource operations.
+//
+// gocql caches keyspace metadata per session and only clears it once schema
+// events are debounced, so resources read the schema from the system_schema
+// tables instead.
+type Client struct {
+	cluster                *gocql.ClusterConfig
+	schemaAgreementTimeout time.Duration
+	statementRetryPolicy   gocql.RetryPolicy
+
+	mutex   sync.Mutex
+	session *gocql.Session
+}
+
+func newClient(cluster *gocql.ClusterConfig, schemaAgreementTimeout time.Duration) *Client {
+	client := &Client{
+		cluster:                cluster,
+		schemaAgreementTimeout: schemaAgreementTimeout,
+		statementRetryPolicy:   cluster.RetryPolicy,
+	}
+
+	if retryPolicy, ok := cluster.RetryPolicy.(*RetryPolicy); ok {
+		client.statementRetryPolicy = retryPolicy.nonIdempotent()
+	}
+
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	clients = append(clients, client)
+
+	return client
+}
+
+// closeClients closes the sessions of every client, called when the plugin exits
+func closeClients() {
+	clientsMutex.Lock()
+	defer clientsMutex.Unlock()
+
+	for _, client := range clients {
+		client.Close()
+	}
+
+	clients = nil
+}
+
+func (client *Client) createSession() (*gocql.Session, error) {
+	start := time.Now()
+
+	session, err := client.cluster.CreateSession()
+
+	elapsed := time.Since(start)
+
+	log.Printf("Getting a session took %s", elapsed)
+
+	return session, err
+}
+
+// Session returns the shared session, creating it on first use or if the
+// previous one was closed. A failed attempt is not cached so the next caller
+// retries, downed hosts are reconnected by gocql every ReconnectInterval.
+func (client *Client) Session() (*gocql.Session, error) {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.session != nil && !client.session.Closed() {
+		return client.session, nil
+	}
+
+	session, err := client.createSession()
+
+	if err != nil {
+		return nil, err
+	}
+
+	client.session = session
+
+	return session, nil
+}
+
+// ExecSchemaChange executes a CREATE, ALTER or DROP statement and waits until
+// every host in the cluster has the resulting schema version. The statement
+// is only retried when it was not executed, see RetryPolicy.
+func (client *Client) ExecSchemaChange(query string) error {
+	session, err := client.Session()
+
+	if err != nil {
+		return err
+	}
+
+	// gocql waits for agreement itself before returning, but only logs when it
+	// is not reached, so the deadline covers both waits
+	start := time.Now()
+
+	err = session.Query(query).RetryPolicy(client.statementRetryPolicy).Exec()
+
+	if err != nil {
+		return err
+	}
+
+	return client.awaitSchemaAgreement(session, start)
+}
+
+// ExecStatement executes a statement which changes roles or permissions, such
+// as CREATE ROLE or GRANT. Like schema changes it is only retried when it was
+// not executed by the cluster.
+func (client *Client) ExecStatement(query string) error {
+	session, err := client.Session()
+
+	if err != nil {
+		return err
+	}
+
+	return session.Query(query).RetryPolicy(client.statementRetryPolicy).Exec()
+}
+
+// sameHost reports whether two hosts have the same address
+func sameHost(host *gocql.HostInfo, other *gocql.HostInfo) bool {
+	return host != nil && other != nil && host.ConnectAddress().Equal(other.ConnectAddress()) && host.Port() == other.Port()
+}
+
+// scanLocalAndPeers scans the rows of peersQuery on system.peers and of
+// localQuery on system.local of the same host, so that every node of the
+// cluster is scanned exactly once. gocql cannot send a query to a given host,
+// so the local query is repeated until it is answered by the host which
+// listed the peers, which the host selection policy gets to in a round.
+func scanLocalAndPeers(session *gocql.Session, peersQuery string, localQuery string, scan func(iter *gocql.Iter)) error {
+	peers := session.Query(peersQuery).Iter()
+	host := peers.Host()
+	attempts := 2 * (peers.NumRows() + 1)
+
+	scan(peers)
+
+	if err := peers.Close(); err != nil {
+		return err
+	}
+
+	if host == nil {
+		return fmt.Errorf("system.peers was read from an unknown host")
+	}
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		local := session.Query(localQuery).Iter()
+
+		if !sameHost(local.Host(), host) {
+			if err := local.Close(); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		scan(local)
+
+		return local.Close()
+	}
+
+	return fmt.Errorf("system.local was not read from %s, the host which listed system.peers", host.ConnectAddress())
+}
+
+// schemaVersions returns the hosts of the cluster keyed by their schema
+// version, as known by a single host
+func schemaVersions(session *gocql.Session) (map[string][]string, error) {
+	hostVersions := make(map[string]string)
+
+	err := scanLocalAndPeers(session,
+		`SELECT peer, schema_version FROM system.peers`,
+		`SELECT broadcast_address, schema_version FROM system.local WHERE key = 'local'`,
+		func(iter *gocql.Iter) {
+			var (
+				host          string
+				schemaVersion string
+			)
+
+			for iter.Scan(&host, &schemaVersion) {
+				// peers which are down or still joining have no schema version
+				if schemaVersion != "" {
+					hostVersions[host] = schemaVersion
+				}
+			}
+		})
+
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[string][]string)
+
+	for host, schemaVersion := range hostVersions {
+		versions[schemaVersion] = append(versions[schemaVersion], host)
+	}
+
+	return versions, nil
+}
+
+func schemaDisagreementError(versions map[string][]string, timeout time.Duration) error {
+	descriptions := make([]string, 0, len(versions))
+
+	for schemaVersion, hosts := range versions {
+		sort.Strings(hosts)
+
+		descriptions = append(descriptions, fmt.Sprintf("%s on %s", schemaVersion, strings.Join(hosts, ", ")))
+	}
+
+	sort.Strings(descriptions)
+
+	return fmt.Errorf("schema agreement not reached after %s, hosts have schema versions %s", timeout, strings.Join(descriptions, "; "))
+}
+
+// awaitSchemaAgreement polls the schema version of every host until they all
+// match or the schema agreement timeout since start expires
+func (client *Client) awaitSchemaAgreement(session *gocql.Session, start time.Time) error {
+	deadline := start.Add(client.schemaAgreementTimeout)
+
+	for {
+		versions, err := schemaVersions(session)
+
+		if err == nil && len(versions) <= 1 {
+			log.Printf("Schema agreement took %s", time.Since(start))
+
+			return nil
+		}
+
+		if time.Now().After(deadline) {
+			if err != nil {
+				return fmt.Errorf("schema agreement not reached after %s: %v", client.schemaAgreementTimeout, err)
+			}
+
+			return schemaDisagreementError(versions, client.schemaAgreementTimeout)
+		}
+
+		time.Sleep(schemaAgreementInterval)
+	}
+}
+
+// Close closes the session held by the client
+func (client *Client) Close() {
+	client.mutex.Lock()
+	defer client.mutex.Unlock()
+
+	if client.session != nil {
+		client.session.Close()
+		client.session = nil
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Cassandra does not accept bind markers in schema and role statements, so
+// those are built with the helpers below. Every identifier is quoted and every
+// string is a literal with its delimiters escaped, so a value taken from the
+// configuration can never end the identifier or literal it is placed in.
+
+// cqlIdentifier returns name as a quoted identifier, doubling embedded double quotes
+func cqlIdentifier(name string) string {
+	return `"` + strings.Replace(name, `"`, `""`, -1) + `"`
+}
+
+// cqlQualifiedName returns the quoted name qualified by its quoted keyspace
+func cqlQualifiedName(keyspace string, name string) string {
+	return cqlIdentifier(keyspace) + "." + cqlIdentifier(name)
+}
+
+// cqlIdentifiers returns the quoted names separated by commas
+func cqlIdentifiers(names []string) string {
+	quoted := make([]string, 0, len(names))
+
+	for _, name := range names {
+		quoted = append(quoted, cqlIdentifier(name))
+	}
+
+	return strings.Join(quoted, ", ")
+}
+
+// cqlString returns value as a string literal, doubling embedded single quotes
+func cqlString(value string) string {
+	return `'` + strings.Replace(value, `'`, `''`, -1) + `'`
+}
+
+// cqlStringMap returns a map literal of string keys and values, sorted by key
+// so that the statement is stable
+func cqlStringMap(values map[string]string) string {
+	keys := make([]string, 0, len(values))
+
+	for key := range values {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf(`%s : %s`, cqlString(key), cqlString(values[key])))
+	}
+
+	return fmt.Sprintf("{ %s }", strings.Join(pairs, ", "))
+}
+
+// cqlFunctionSignature returns the quoted keyspace and function name followed
+// by the argument types, which are types rather than identifiers
+func cqlFunctionSignature(keyspace string, name string, argumentTypes []string) string {
+	return fmt.Sprintf("%s(%s)", cqlQualifiedName(keyspace, name), strings.Join(argumentTypes, ", "))
+}
+
+// unquoteIdentifier returns name without the double quotes it was written with,
+// undoubling embedded double quotes. Names are always quoted when they are
+// placed in a statement, so "MyKeyspace" and MyKeyspace name the same object
+// and the case of the name is preserved either way
+func unquoteIdentifier(name string) string {
+	if len(name) < 2 || !strings.HasPrefix(name, `"`) || !strings.HasSuffix(name, `"`) {
+		return name
+	}
+
+	return strings.Replace(name[1:len(name)-1], `""`, `"`, -1)
+}
+
+// identifierStateFunc stores an identifier without its quotes, so that
+// quoting a name in the configuration does not produce a diff against the
+// name read back from the cluster
+func identifierStateFunc(v interface{}) string {
+	return unquoteIdentifier(v.(string))
+}
+
+// getIdentifier returns the identifier held in key without its quotes
+func getIdentifier(d resourceGetter, key string) string {
+	return unquoteIdentifier(d.Get(key).(string))
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+// readCQLString reads the string literal starting at offset the way the CQL
+// lexer does, returning its value and the offset after its closing quote
+func readCQLString(statement string, offset int) (string, int, bool) {
+	if offset >= len(statement) || statement[offset] != '\'' {
+		return "", 0, false
+	}
+
+	var value strings.Builder
+
+	for index := offset + 1; index < len(statement); index++ {
+		if statement[index] != '\'' {
+			value.WriteByte(statement[index])
+			continue
+		}
+
+		if index+1 < len(statement) && statement[index+1] == '\'' {
+			value.WriteByte('\'')
+			index++
+			continue
+		}
+
+		return value.String(), index + 1, true
+	}
+
+	return "", 0, false
+}
+
+// readCQLStringMap reads a map literal of string keys and values
+func readCQLStringMap(statement string) (map[string]string, bool) {
+	values := make(map[string]string)
+
+	if !strings.HasPrefix(statement, "{ ") || !strings.HasSuffix(statement, " }") {
+		return nil, false
+	}
+
+	offset := 2
+
+	for offset < len(statement)-2 {
+		key, next, ok := readCQLString(statement, offset)
+
+		if !ok || !strings.HasPrefix(statement[next:], " : ") {
+			return nil, false
+		}
+
+		value, next, ok := readCQLString(statement, next+3)
+
+		if !ok {
+			return nil, false
+		}
+
+		values[key] = value
+		offset = next
+
+		if strings.HasPrefix(statement[offset:], ", ") {
+			offset += 2
+		}
+	}
+
+	return values, offset == len(statement)-2
+}
+
+func TestCQLIdentifierRoundTrip(t *testing.T) {
+	roundTrip := func(name string) bool {
+		return unquoteIdentifier(cqlIdentifier(name)) == name
+	}
+
+	if err := quick.Check(roundTrip, nil); err != nil {
+		t.Error(err)
+	}
+
+	for _, name := range []string{"", `"`, `""`, `"MyKs"`, `a"b`, `x" ; DROP KEYSPACE ks; --`} {
+		if !roundTrip(name) {
+			t.Errorf("%q does not round trip through %s", name, cqlIdentifier(name))
+		}
+	}
+}
+
+func TestCQLStringDoesNotEndEarly(t *testing.T) {
+	literal := func(value string) bool {
+		quoted := cqlString(value)
+		read, end, ok := readCQLString(quoted, 0)
+
+		return ok && end == len(quoted) && read == value
+	}
+
+	if err := quick.Check(literal, nil); err != nil {
+		t.Error(err)
+	}
+
+	for _, value := range []string{"", "'", "''", "it's", `'; DROP ROLE admin; --`, `"'"`} {
+		if !literal(value) {
+			t.Errorf("%q ends early in %s", value, cqlString(value))
+		}
+	}
+}
+
+func TestCQLStringMapStable(t *testing.T) {
+	stable := func(values map[string]string) bool {
+		copied := make(map[string]string, len(values))
+
+		for key, value := range values {
+			copied[key] = value
+		}
+
+		statement := cqlStringMap(values)
+
+		if statement != cqlStringMap(copied) {
+			return false
+		}
+
+		if len(values) == 0 {
+			return statement == "{  }"
+		}
+
+		read, ok := readCQLStringMap(statement)
+
+		if !ok || len(read) != len(values) {
+			return false
+		}
+
+		for key, value := range values {
+			if read[key] != value {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	if err := quick.Check(stable, nil); err != nil {
+		t.Error(err)
+	}
+
+	if !stable(map[string]string{"a'b": `c"d`, `"`: "'", "class": "SimpleStrategy", "x' : 'y": "z', 'w"}) {
+		t.Error("map with quotes is not read back as written")
+	}
+}
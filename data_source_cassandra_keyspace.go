@@ -0,0 +1,80 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceCassandraKeyspace() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceKeyspaceRead,
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of keyspace",
+			},
+			"replication_strategy": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Keyspace replication strategy",
+			},
+			"replication": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Replication of the keyspace, replication_factor for SimpleStrategy or the replication factor of each datacenter for NetworkTopologyStrategy",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"replication_factor": &schema.Schema{
+							Type:     schema.TypeInt,
+							Computed: true,
+						},
+						"datacenters": &schema.Schema{
+							Type:     schema.TypeMap,
+							Computed: true,
+							Elem: &schema.Schema{
+								Type: schema.TypeInt,
+							},
+						},
+					},
+				},
+			},
+			"strategy_options": &schema.Schema{
+				Type:        schema.TypeMap,
+				Computed:    true,
+				Description: "strategy options used with replication strategy",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"durable_writes": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether durable writes are enabled",
+			},
+		},
+	}
+}
+
+func dataSourceKeyspaceRead(d *schema.ResourceData, meta interface{}) error {
+	name := getIdentifier(d, "name")
+
+	keyspaceMetadata, name, err := readKeyspaceMetadata(meta.(*Client), name)
+
+	if err != nil {
+		return err
+	}
+
+	strategyOptions := keyspaceMetadata.StrategyOptions
+
+	strategyClass := strings.TrimPrefix(keyspaceMetadata.StrategyClass, "org.apache.cassandra.locator.")
+
+	d.Set("replication_strategy", strategyClass)
+	d.Set("durable_writes", keyspaceMetadata.DurableWrites)
+	d.Set("strategy_options", strategyOptions)
+	d.Set("replication", flattenReplication(strategyClass, strategyOptions))
+	d.SetId(name)
+
+	return nil
+}
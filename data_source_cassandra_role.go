@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceCassandraRole() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceRoleRead,
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of role",
+			},
+			"super_user": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the role can create and manage other roles",
+			},
+			"login": &schema.Schema{
+				Type:        schema.TypeBool,
+				Computed:    true,
+				Description: "Whether the role is able to login",
+			},
+			identifierConsistency: authConsistencySchema(),
+		},
+	}
+}
+
+func dataSourceRoleRead(d *schema.ResourceData, meta interface{}) error {
+	name := getIdentifier(d, "name")
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return sessionCreateError
+	}
+
+	_name, login, superUser, _, readRoleErr := readRole(session, name, authConsistency(d))
+
+	if readRoleErr != nil {
+		return readRoleErr
+	}
+
+	if _name != name {
+		return fmt.Errorf("role %s does not exist", name)
+	}
+
+	d.SetId(_name)
+	d.Set("super_user", superUser)
+	d.Set("login", login)
+
+	return nil
+}
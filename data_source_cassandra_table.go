@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func dataSourceCassandraTable() *schema.Resource {
+	return &schema.Resource{
+		Read: dataSourceTableRead,
+		Schema: map[string]*schema.Schema{
+			"keyspace": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the keyspace the table belongs to",
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the table",
+			},
+			"column": &schema.Schema{
+				Type:        schema.TypeSet,
+				Computed:    true,
+				Description: "Columns of the table",
+				Set:         tableColumnHash,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"type": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"static": &schema.Schema{
+							Type:     schema.TypeBool,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"partition_keys": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Ordered list of column names making up the partition key",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"clustering_key": &schema.Schema{
+				Type:        schema.TypeList,
+				Computed:    true,
+				Description: "Ordered list of clustering columns",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+						"order": &schema.Schema{
+							Type:     schema.TypeString,
+							Computed: true,
+						},
+					},
+				},
+			},
+			"compaction": &schema.Schema{
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"compression": &schema.Schema{
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"caching": &schema.Schema{
+				Type:     schema.TypeMap,
+				Computed: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"gc_grace_seconds": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+			"default_time_to_live": &schema.Schema{
+				Type:     schema.TypeInt,
+				Computed: true,
+			},
+		},
+	}
+}
+
+func dataSourceTableRead(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	err := readTable(d, meta.(*Client), keyspace, name)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s.%s", keyspace, name))
+
+	return nil
+}
@@ -3,16 +3,28 @@ package main
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/pem"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
+	"os"
+	"strings"
 	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 )
 
+const (
+	hostSelectionPolicyRoundRobin        = "round_robin"
+	hostSelectionPolicyDCAwareRoundRobin = "dc_aware_round_robin"
+	hostSelectionPolicyTokenAware        = "token_aware"
+)
+
 var (
+	allowedHostSelectionPolicies = []string{hostSelectionPolicyRoundRobin, hostSelectionPolicyDCAwareRoundRobin, hostSelectionPolicyTokenAware}
+
 	allowedTLSProtocols = map[string]uint16{
 		"SSL3.0": tls.VersionSSL30,
 		"TLS1.0": tls.VersionTLS10,
@@ -25,30 +37,43 @@ var (
 func Provider() *schema.Provider {
 	return &schema.Provider{
 		ResourcesMap: map[string]*schema.Resource{
-			"cassandra_keyspace": resourceCassandraKeyspace(),
-			"cassandra_role":     resourceCassandraRole(),
-			"cassandra_grant":    resourceCassandraGrant(),
+			"cassandra_keyspace":          resourceCassandraKeyspace(),
+			"cassandra_role":              resourceCassandraRole(),
+			"cassandra_grant":             resourceCassandraGrant(),
+			"cassandra_table":             resourceCassandraTable(),
+			"cassandra_type":              resourceCassandraType(),
+			"cassandra_role_membership":   resourceCassandraRoleMembership(),
+			"cassandra_function":          resourceCassandraFunction(),
+			"cassandra_aggregate":         resourceCassandraAggregate(),
+			"cassandra_index":             resourceCassandraIndex(),
+			"cassandra_materialized_view": resourceCassandraMaterializedView(),
+			"cassandra_role_grants":       resourceCassandraRoleGrants(),
+		},
+		DataSourcesMap: map[string]*schema.Resource{
+			"cassandra_keyspace": dataSourceCassandraKeyspace(),
+			"cassandra_role":     dataSourceCassandraRole(),
+			"cassandra_table":    dataSourceCassandraTable(),
 		},
 		ConfigureFunc: configureProvider,
 		Schema: map[string]*schema.Schema{
 			"username": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
-				Default:     "",
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_USERNAME", ""),
 				Description: "Cassandra Username",
 				Sensitive:   true,
 			},
 			"password": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
-				Default:     "",
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_PASSWORD", ""),
 				Description: "Cassandra Password",
 				Sensitive:   true,
 			},
 			"port": &schema.Schema{
 				Type:        schema.TypeInt,
 				Optional:    true,
-				Default:     9042,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_PORT", 9042),
 				Description: "Cassandra CQL Port",
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
 					port := i.(int)
@@ -65,18 +90,124 @@ func Provider() *schema.Provider {
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
-				MinItems: 1,
-				Required: true,
+				Optional:    true,
+				Description: "Hosts of the cluster, falls back to a comma separated list in CASSANDRA_HOSTS",
+			},
+			"local_datacenter": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_LOCAL_DATACENTER", ""),
+				Description: "Datacenter whose hosts are queried first, hosts in other datacenters are only used when none of them are up. Requires discover_hosts",
+			},
+			"discover_hosts": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_DISCOVER_HOSTS", false),
+				Description: "Discover the other hosts of the cluster from the configured hosts instead of only connecting to the configured hosts",
+			},
+			"host_selection_policy": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_HOST_SELECTION_POLICY", hostSelectionPolicyTokenAware),
+				Description: fmt.Sprintf("Policy used to pick the host of each query - allowed values are %s", strings.Join(allowedHostSelectionPolicies, ", ")),
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					policy := i.(string)
+
+					for _, allowedPolicy := range allowedHostSelectionPolicies {
+						if policy == allowedPolicy {
+							return
+						}
+					}
+
+					errors = append(errors, fmt.Errorf("%s: invalid value - must be one of %s", policy, strings.Join(allowedHostSelectionPolicies, ", ")))
+
+					return
+				},
+			},
+			"consistency": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CASSANDRA_CONSISTENCY", "QUORUM"),
+				Description:  fmt.Sprintf("Default consistency level of queries, roles and permissions are read with the consistency of the resource - allowed values are %s", strings.Join(allowedConsistencies, ", ")),
+				ValidateFunc: validConsistency,
+			},
+			"serial_consistency": &schema.Schema{
+				Type:         schema.TypeString,
+				Optional:     true,
+				DefaultFunc:  schema.EnvDefaultFunc("CASSANDRA_SERIAL_CONSISTENCY", "SERIAL"),
+				Description:  fmt.Sprintf("Serial consistency level of conditional statements - allowed values are %s", strings.Join(allowedSerialConsistencies, ", ")),
+				ValidateFunc: validSerialConsistency,
 			},
 			"connection_timeout": &schema.Schema{
 				Type:        schema.TypeInt,
 				Optional:    true,
-				Default:     1000,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_CONNECTION_TIMEOUT", 1000),
 				Description: "Connection timeout in milliseconds",
 			},
+			"query_timeout": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_QUERY_TIMEOUT", 60),
+				Description: "Time in seconds to wait for the response to a query",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					value := i.(int)
+
+					if value <= 0 {
+						errors = append(errors, fmt.Errorf("%d: invalid value - must be greater than 0", value))
+					}
+
+					return
+				},
+			},
+			"retry_attempts": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_RETRY_ATTEMPTS", 3),
+				Description: "Number of times a query which failed with a timeout, unavailable or overloaded error is retried",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					value := i.(int)
+
+					if value < 0 {
+						errors = append(errors, fmt.Errorf("%d: invalid value - must not be negative", value))
+					}
+
+					return
+				},
+			},
+			"retry_min_backoff": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_RETRY_MIN_BACKOFF", 100),
+				Description: "Time in milliseconds to wait before the second retry of a query, doubled for every retry after it",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					value := i.(int)
+
+					if value <= 0 {
+						errors = append(errors, fmt.Errorf("%d: invalid value - must be greater than 0", value))
+					}
+
+					return
+				},
+			},
+			"retry_max_backoff": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_RETRY_MAX_BACKOFF", 10000),
+				Description: "Maximum time in milliseconds to wait between retries of a query",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					value := i.(int)
+
+					if value <= 0 {
+						errors = append(errors, fmt.Errorf("%d: invalid value - must be greater than 0", value))
+					}
+
+					return
+				},
+			},
 			"root_ca": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_ROOT_CA", ""),
 				Description: "Use root CA to connect to Cluster. Applies only when useSSL is enabled",
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
 					rootCA := i.(string)
@@ -95,16 +226,81 @@ func Provider() *schema.Provider {
 					return
 				},
 			},
+			"client_cert": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_CLIENT_CERT", ""),
+				Description: "PEM encoded client certificate, or a path to one, used for client certificate authentication. Applies only when useSSL is enabled",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					clientCert := i.(string)
+
+					if clientCert == "" {
+						return
+					}
+
+					block, err := readPEMBlock(clientCert)
+
+					if err != nil {
+						errors = append(errors, fmt.Errorf("%s: %v", s, err))
+						return
+					}
+
+					if _, err := x509.ParseCertificate(block.Bytes); block.Type != "CERTIFICATE" || err != nil {
+						errors = append(errors, fmt.Errorf("%s: invalid PEM certificate", s))
+					}
+
+					return
+				},
+			},
+			"client_key": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Sensitive:   true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_CLIENT_KEY", ""),
+				Description: "PEM encoded private key of the client certificate, or a path to one. Applies only when useSSL is enabled",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					clientKey := i.(string)
+
+					if clientKey == "" {
+						return
+					}
+
+					block, err := readPEMBlock(clientKey)
+
+					if err != nil {
+						errors = append(errors, fmt.Errorf("%s: %v", s, err))
+						return
+					}
+
+					if !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+						errors = append(errors, fmt.Errorf("%s: invalid PEM private key", s))
+					}
+
+					return
+				},
+			},
+			"server_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_SERVER_NAME", ""),
+				Description: "Server name sent with SNI and verified against the certificate of every host. Applies only when useSSL is enabled",
+			},
+			"insecure_skip_verify": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_INSECURE_SKIP_VERIFY", false),
+				Description: "Skip verification of the certificates presented by the cluster - not recommended. Applies only when useSSL is enabled",
+			},
 			"use_ssl": &schema.Schema{
 				Type:        schema.TypeBool,
 				Optional:    true,
-				Default:     false,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_USE_SSL", false),
 				Description: "Use SSL when connecting to cluster",
 			},
 			"min_tls_version": &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
-				Default:     "TLS1.2",
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_MIN_TLS_VERSION", "TLS1.2"),
 				Description: "Minimum TLS Version used to connect to the cluster - allowed values are SSL3.0, TLS1.0, TLS1.1, TLS1.2. Applies only when useSSL is enabled",
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
 					minTLSVersion := i.(string)
@@ -119,13 +315,148 @@ func Provider() *schema.Provider {
 			"protocol_version": &schema.Schema{
 				Type:        schema.TypeInt,
 				Optional:    true,
-				Default:     4,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_PROTOCOL_VERSION", 4),
 				Description: "CQL Binary Protocol Version",
 			},
+			"schema_agreement_timeout": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				DefaultFunc: schema.EnvDefaultFunc("CASSANDRA_SCHEMA_AGREEMENT_TIMEOUT", 60),
+				Description: "Time in seconds to wait for all hosts to agree on the schema after a schema change",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					timeout := i.(int)
+
+					if timeout <= 0 {
+						errors = append(errors, fmt.Errorf("%d: invalid value - must be greater than 0", timeout))
+					}
+
+					return
+				},
+			},
 		},
 	}
 }
 
+// readPEM returns value when it holds PEM content, otherwise it reads the file
+// at the path in value
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+
+	return ioutil.ReadFile(value)
+}
+
+func readPEMBlock(value string) (*pem.Block, error) {
+	content, err := readPEM(value)
+
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(content)
+
+	if block == nil {
+		return nil, errors.New("invalid PEM")
+	}
+
+	return block, nil
+}
+
+// verifyCertificateChain verifies the certificate chain presented by a host
+// without checking its hostname, as gocql does not set a server name per host
+func verifyCertificateChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("host did not present a certificate")
+		}
+
+		certificates := make([]*x509.Certificate, 0, len(rawCerts))
+
+		for _, rawCert := range rawCerts {
+			certificate, err := x509.ParseCertificate(rawCert)
+
+			if err != nil {
+				return err
+			}
+
+			certificates = append(certificates, certificate)
+		}
+
+		intermediates := x509.NewCertPool()
+
+		for _, certificate := range certificates[1:] {
+			intermediates.AddCert(certificate)
+		}
+
+		_, err := certificates[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+
+		return err
+	}
+}
+
+// providerHosts returns the configured hosts, falling back to the comma
+// separated CASSANDRA_HOSTS environment variable
+func providerHosts(d *schema.ResourceData) ([]string, error) {
+	rawHosts := d.Get("hosts").([]interface{})
+
+	hosts := make([]string, 0, len(rawHosts))
+
+	for _, value := range rawHosts {
+		hosts = append(hosts, value.(string))
+	}
+
+	if len(hosts) == 0 {
+		for _, host := range strings.Split(os.Getenv("CASSANDRA_HOSTS"), ",") {
+			host = strings.TrimSpace(host)
+
+			if host != "" {
+				hosts = append(hosts, host)
+			}
+		}
+	}
+
+	if len(hosts) == 0 {
+		return nil, errors.New("hosts must be set in the provider configuration or in the CASSANDRA_HOSTS environment variable")
+	}
+
+	return hosts, nil
+}
+
+// hostSelectionPolicy returns the named policy, preferring the hosts of the
+// local datacenter when one is set. Token awareness falls back to the
+// datacenter aware or round robin policy for statements without a routing key,
+// which includes every schema change
+func hostSelectionPolicy(policy string, localDatacenter string) (gocql.HostSelectionPolicy, error) {
+	fallback := gocql.RoundRobinHostPolicy()
+
+	if localDatacenter != "" {
+		fallback = gocql.DCAwareRoundRobinPolicy(localDatacenter)
+	}
+
+	switch policy {
+	case hostSelectionPolicyRoundRobin:
+		if localDatacenter != "" {
+			return nil, fmt.Errorf("local_datacenter requires host_selection_policy %s or %s", hostSelectionPolicyDCAwareRoundRobin, hostSelectionPolicyTokenAware)
+		}
+
+		return fallback, nil
+	case hostSelectionPolicyDCAwareRoundRobin:
+		if localDatacenter == "" {
+			return nil, fmt.Errorf("host_selection_policy %s requires local_datacenter", hostSelectionPolicyDCAwareRoundRobin)
+		}
+
+		return fallback, nil
+	case hostSelectionPolicyTokenAware:
+		return gocql.TokenAwareHostPolicy(fallback), nil
+	}
+
+	return nil, fmt.Errorf("%s: invalid host_selection_policy", policy)
+}
+
 func configureProvider(d *schema.ResourceData) (interface{}, error) {
 
 	log.Printf("Creating provider")
@@ -136,19 +467,24 @@ func configureProvider(d *schema.ResourceData) (interface{}, error) {
 	port := d.Get("port").(int)
 	connectionTimeout := d.Get("connection_timeout").(int)
 	protocolVersion := d.Get("protocol_version").(int)
+	schemaAgreementTimeout := time.Second * time.Duration(d.Get("schema_agreement_timeout").(int))
+	localDatacenter := d.Get("local_datacenter").(string)
+	discoverHosts := d.Get("discover_hosts").(bool)
 
 	log.Printf("Using port %d", port)
 	log.Printf("Using use_ssl %v", useSSL)
 	log.Printf("Using username %s", username)
+	log.Printf("Using local_datacenter %s", localDatacenter)
+	log.Printf("Using discover_hosts %v", discoverHosts)
 
-	rawHosts := d.Get("hosts").([]interface{})
-
-	hosts := make([]string, len(rawHosts))
+	hosts, err := providerHosts(d)
 
-	for _, value := range rawHosts {
-		hosts = append(hosts, value.(string))
+	if err != nil {
+		return nil, err
+	}
 
-		log.Printf("Using host %v", value.(string))
+	for _, host := range hosts {
+		log.Printf("Using host %v", host)
 	}
 
 	cluster := gocql.NewCluster()
@@ -164,17 +500,57 @@ func configureProvider(d *schema.ResourceData) (interface{}, error) {
 
 	cluster.ConnectTimeout = time.Millisecond * time.Duration(connectionTimeout)
 
-	cluster.Timeout = time.Minute * time.Duration(1)
+	cluster.Timeout = time.Second * time.Duration(d.Get("query_timeout").(int))
+
+	retryPolicy := &RetryPolicy{
+		Attempts:   d.Get("retry_attempts").(int),
+		MinBackoff: time.Millisecond * time.Duration(d.Get("retry_min_backoff").(int)),
+		MaxBackoff: time.Millisecond * time.Duration(d.Get("retry_max_backoff").(int)),
+	}
+
+	cluster.RetryPolicy = retryPolicy
 
 	cluster.CQLVersion = "3.0.0"
 
+	consistency, err := gocql.ParseConsistencyWrapper(d.Get("consistency").(string))
+
+	if err != nil {
+		return nil, err
+	}
+
+	cluster.Consistency = consistency
+
+	if err := cluster.SerialConsistency.UnmarshalText([]byte(strings.ToUpper(d.Get("serial_consistency").(string)))); err != nil {
+		return nil, err
+	}
+
 	cluster.Keyspace = "system"
 
 	cluster.ProtoVersion = protocolVersion
 
-	cluster.HostFilter = gocql.WhiteListHostFilter(hosts...)
+	cluster.MaxWaitSchemaAgreement = schemaAgreementTimeout
+
+	// without the initial host lookup gocql does not know the datacenter of
+	// the configured hosts, so every host would be treated as remote
+	if localDatacenter != "" && !discoverHosts {
+		return nil, errors.New("local_datacenter requires discover_hosts = true")
+	}
+
+	policy, err := hostSelectionPolicy(d.Get("host_selection_policy").(string), localDatacenter)
+
+	if err != nil {
+		return nil, err
+	}
 
-	cluster.DisableInitialHostLookup = true
+	cluster.PoolConfig.HostSelectionPolicy = &retryHostPolicy{policy, retryPolicy}
+
+	if !discoverHosts {
+		// only the configured hosts are used, e.g. when the addresses the
+		// nodes advertise are not reachable from where Terraform runs
+		cluster.HostFilter = gocql.WhiteListHostFilter(hosts...)
+
+		cluster.DisableInitialHostLookup = true
+	}
 
 	if useSSL {
 
@@ -196,10 +572,51 @@ func configureProvider(d *schema.ResourceData) (interface{}, error) {
 			tlsConfig.RootCAs = caPool
 		}
 
+		clientCert := d.Get("client_cert").(string)
+		clientKey := d.Get("client_key").(string)
+
+		if clientCert != "" || clientKey != "" {
+			if clientCert == "" || clientKey == "" {
+				return nil, errors.New("client_cert and client_key must be set together")
+			}
+
+			certPEM, err := readPEM(clientCert)
+
+			if err != nil {
+				return nil, err
+			}
+
+			keyPEM, err := readPEM(clientKey)
+
+			if err != nil {
+				return nil, err
+			}
+
+			certificate, err := tls.X509KeyPair(certPEM, keyPEM)
+
+			if err != nil {
+				return nil, fmt.Errorf("Unable to load client certificate: %v", err)
+			}
+
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+
+		serverName := d.Get("server_name").(string)
+		insecureSkipVerify := d.Get("insecure_skip_verify").(bool)
+
+		tlsConfig.ServerName = serverName
+
+		if !insecureSkipVerify && serverName == "" {
+			tlsConfig.VerifyPeerCertificate = verifyCertificateChain(tlsConfig.RootCAs)
+		}
+
 		cluster.SslOpts = &gocql.SslOptions{
 			Config: tlsConfig,
+			// gocql sets InsecureSkipVerify to the inverse of this, hostnames
+			// can only be verified against an explicit server name
+			EnableHostVerification: !insecureSkipVerify && serverName != "",
 		}
 	}
 
-	return cluster, nil
+	return newClient(cluster, schemaAgreementTimeout), nil
 }
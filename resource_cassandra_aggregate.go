@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func resourceCassandraAggregate() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceAggregateCreate,
+		Read:   resourceAggregateRead,
+		Update: resourceAggregateUpdate,
+		Delete: resourceAggregateDelete,
+		Exists: resourceAggregateExists,
+		Schema: map[string]*schema.Schema{
+			"keyspace": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the keyspace the aggregate belongs to",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "keyspace", keyspaceRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the aggregate",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "aggregate name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"argument_types": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Ordered CQL types of the values being aggregated",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+					DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+						return normalizeColumnType(old) == normalizeColumnType(new)
+					},
+				},
+			},
+			"state_function": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the function called for every row, it takes the state type followed by the argument types",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "function name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"state_type": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "CQL type of the state passed between calls of the state function",
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeColumnType(old) == normalizeColumnType(new)
+				},
+			},
+			// required as gocql cannot read the metadata of a keyspace holding
+			// an aggregate without a final function
+			"final_function": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Name of the function called with the final state, it takes the state type",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "function name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"initial_condition": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "CQL literal of the initial state e.g. 0 or (0, 0)",
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return strings.Replace(old, " ", "", -1) == strings.Replace(new, " ", "", -1)
+				},
+			},
+			"return_type": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "CQL type returned by the aggregate",
+			},
+		},
+	}
+}
+
+// Aggregate represents a Cassandra user defined aggregate as stored in system_schema.aggregates
+type Aggregate struct {
+	ArgumentTypes    []string
+	StateFunction    string
+	StateType        string
+	FinalFunction    string
+	InitialCondition string
+	ReturnType       string
+}
+
+func aggregateArgumentTypes(raw []interface{}) []string {
+	types := make([]string, 0, len(raw))
+
+	for _, value := range raw {
+		types = append(types, normalizeColumnType(value.(string)))
+	}
+
+	return types
+}
+
+func readAggregate(session *gocql.Session, keyspace string, name string, argumentTypes []string) (*Aggregate, bool, error) {
+	aggregate := &Aggregate{}
+
+	iter := session.Query(`SELECT argument_types, state_func, state_type, final_func, initcond, return_type FROM system_schema.aggregates WHERE keyspace_name = ? AND aggregate_name = ? AND argument_types = ?`, keyspace, name, argumentTypes).Iter()
+
+	found := iter.Scan(&aggregate.ArgumentTypes, &aggregate.StateFunction, &aggregate.StateType, &aggregate.FinalFunction, &aggregate.InitialCondition, &aggregate.ReturnType)
+
+	if err := iter.Close(); err != nil {
+		return nil, false, err
+	}
+
+	return aggregate, found, nil
+}
+
+// validateAggregateFunctions checks the state and final functions exist in the
+// keyspace with the signatures the aggregate will call them with
+func validateAggregateFunctions(session *gocql.Session, keyspace string, argumentTypes []string, stateFunction string, stateType string, finalFunction string) error {
+	stateType = normalizeColumnType(stateType)
+
+	signatures := map[string][]string{
+		stateFunction: append([]string{stateType}, argumentTypes...),
+		finalFunction: {stateType},
+	}
+
+	for _, name := range []string{stateFunction, finalFunction} {
+		_, found, err := readFunction(session, keyspace, name, signatures[name])
+
+		if err != nil {
+			return err
+		}
+
+		if !found {
+			return fmt.Errorf("function %s does not exist in keyspace %s", functionSignature(name, signatures[name]), keyspace)
+		}
+	}
+
+	return nil
+}
+
+func generateCreateAggregateQueryString(d *schema.ResourceData) string {
+	query := fmt.Sprintf(`CREATE OR REPLACE AGGREGATE %s SFUNC %s STYPE %s FINALFUNC %s`,
+		cqlFunctionSignature(getIdentifier(d, "keyspace"), getIdentifier(d, "name"), aggregateArgumentTypes(d.Get("argument_types").([]interface{}))),
+		cqlIdentifier(getIdentifier(d, "state_function")),
+		d.Get("state_type").(string),
+		cqlIdentifier(getIdentifier(d, "final_function")),
+	)
+
+	// the initial condition is a CQL literal written in the configuration
+	if initialCondition := d.Get("initial_condition").(string); initialCondition != "" {
+		query += fmt.Sprintf(` INITCOND %s`, initialCondition)
+	}
+
+	log.Println("query", query)
+
+	return query
+}
+
+func resourceAggregateExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	argumentTypes := aggregateArgumentTypes(d.Get("argument_types").([]interface{}))
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return false, sessionCreateError
+	}
+
+	_, found, err := readAggregate(session, keyspace, name, argumentTypes)
+
+	return found, err
+}
+
+func resourceAggregateCreateOrUpdate(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	argumentTypes := aggregateArgumentTypes(d.Get("argument_types").([]interface{}))
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return sessionCreateError
+	}
+
+	err := validateAggregateFunctions(session, keyspace, argumentTypes, getIdentifier(d, "state_function"), d.Get("state_type").(string), getIdentifier(d, "final_function"))
+
+	if err != nil {
+		return err
+	}
+
+	err = meta.(*Client).ExecSchemaChange(generateCreateAggregateQueryString(d))
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s.%s", keyspace, functionSignature(name, argumentTypes)))
+
+	return resourceAggregateRead(d, meta)
+}
+
+func resourceAggregateCreate(d *schema.ResourceData, meta interface{}) error {
+	return resourceAggregateCreateOrUpdate(d, meta)
+}
+
+func resourceAggregateRead(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	argumentTypes := aggregateArgumentTypes(d.Get("argument_types").([]interface{}))
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return sessionCreateError
+	}
+
+	aggregate, found, err := readAggregate(session, keyspace, name, argumentTypes)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("aggregate %s does not exist in keyspace %s", functionSignature(name, argumentTypes), keyspace)
+	}
+
+	d.Set("argument_types", aggregate.ArgumentTypes)
+	d.Set("state_function", aggregate.StateFunction)
+	d.Set("state_type", aggregate.StateType)
+	d.Set("final_function", aggregate.FinalFunction)
+	d.Set("initial_condition", aggregate.InitialCondition)
+	d.Set("return_type", aggregate.ReturnType)
+
+	return nil
+}
+
+func resourceAggregateUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceAggregateCreateOrUpdate(d, meta)
+}
+
+func resourceAggregateDelete(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	argumentTypes := aggregateArgumentTypes(d.Get("argument_types").([]interface{}))
+
+	return meta.(*Client).ExecSchemaChange(fmt.Sprintf(`DROP AGGREGATE %s`, cqlFunctionSignature(keyspace, name, argumentTypes)))
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+var (
+	functionLanguageRegex, _ = regexp.Compile(`^[a-zA-Z][a-zA-Z0-9_]*$`)
+)
+
+func resourceCassandraFunction() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceFunctionCreate,
+		Read:   resourceFunctionRead,
+		Update: resourceFunctionUpdate,
+		Delete: resourceFunctionDelete,
+		Exists: resourceFunctionExists,
+		Schema: map[string]*schema.Schema{
+			"keyspace": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the keyspace the function belongs to",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "keyspace", keyspaceRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the function",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "function name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"argument": &schema.Schema{
+				Type:        schema.TypeList,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Ordered arguments of the function",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "Name of the argument",
+							ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+								return validIdentifier(i, s, "argument name", columnNameRegex)
+							},
+						},
+						"type": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							ForceNew:    true,
+							Description: "CQL type of the argument",
+							DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+								return normalizeColumnType(old) == normalizeColumnType(new)
+							},
+						},
+					},
+				},
+			},
+			"return_type": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "CQL type returned by the function",
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeColumnType(old) == normalizeColumnType(new)
+				},
+			},
+			"language": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Default:     "java",
+				Description: "Language the body is written in e.g. java or javascript",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validIdentifier(i, s, "language", functionLanguageRegex)
+				},
+			},
+			"called_on_null_input": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     false,
+				ForceNew:    true,
+				Description: "Call the function when an argument is null (CALLED ON NULL INPUT) instead of returning null (RETURNS NULL ON NULL INPUT)",
+			},
+			"body": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: "Body of the function",
+			},
+			"signature": &schema.Schema{
+				Type:        schema.TypeString,
+				Computed:    true,
+				Description: "Name and argument types of the function e.g. my_function(int, text), usable as function_name of cassandra_grant",
+			},
+		},
+	}
+}
+
+// FunctionArgument represents an argument of a Cassandra user defined function
+type FunctionArgument struct {
+	Name string
+	Type string
+}
+
+func functionArgumentsFromList(raw []interface{}) []FunctionArgument {
+	arguments := make([]FunctionArgument, 0, len(raw))
+
+	for _, value := range raw {
+		argument := value.(map[string]interface{})
+
+		arguments = append(arguments, FunctionArgument{argument["name"].(string), argument["type"].(string)})
+	}
+
+	return arguments
+}
+
+func functionArgumentTypes(arguments []FunctionArgument) []string {
+	types := make([]string, 0, len(arguments))
+
+	for _, argument := range arguments {
+		types = append(types, normalizeColumnType(argument.Type))
+	}
+
+	return types
+}
+
+func functionSignature(name string, argumentTypes []string) string {
+	return fmt.Sprintf("%s(%s)", name, strings.Join(argumentTypes, ", "))
+}
+
+func generateCreateFunctionQueryString(d *schema.ResourceData) string {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	arguments := functionArgumentsFromList(d.Get("argument").([]interface{}))
+
+	definitions := make([]string, 0, len(arguments))
+
+	for _, argument := range arguments {
+		definitions = append(definitions, fmt.Sprintf("%s %s", cqlIdentifier(argument.Name), argument.Type))
+	}
+
+	onNullInput := "RETURNS NULL"
+
+	if d.Get("called_on_null_input").(bool) {
+		onNullInput = "CALLED"
+	}
+
+	query := fmt.Sprintf(`CREATE OR REPLACE FUNCTION %s (%s) %s ON NULL INPUT RETURNS %s LANGUAGE %s AS %s`,
+		cqlQualifiedName(keyspace, name),
+		strings.Join(definitions, ", "),
+		onNullInput,
+		d.Get("return_type").(string),
+		d.Get("language").(string),
+		cqlString(d.Get("body").(string)),
+	)
+
+	log.Println("query", query)
+
+	return query
+}
+
+// Function represents a Cassandra user defined function as stored in system_schema.functions
+type Function struct {
+	ArgumentNames     []string
+	ArgumentTypes     []string
+	Body              string
+	CalledOnNullInput bool
+	Language          string
+	ReturnType        string
+}
+
+func readFunction(session *gocql.Session, keyspace string, name string, argumentTypes []string) (*Function, bool, error) {
+	function := &Function{}
+
+	iter := session.Query(`SELECT argument_names, argument_types, body, called_on_null_input, language, return_type FROM system_schema.functions WHERE keyspace_name = ? AND function_name = ? AND argument_types = ?`, keyspace, name, argumentTypes).Iter()
+
+	found := iter.Scan(&function.ArgumentNames, &function.ArgumentTypes, &function.Body, &function.CalledOnNullInput, &function.Language, &function.ReturnType)
+
+	if err := iter.Close(); err != nil {
+		return nil, false, err
+	}
+
+	return function, found, nil
+}
+
+func resourceFunctionExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	argumentTypes := functionArgumentTypes(functionArgumentsFromList(d.Get("argument").([]interface{})))
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return false, sessionCreateError
+	}
+
+	_, found, err := readFunction(session, keyspace, name, argumentTypes)
+
+	return found, err
+}
+
+func resourceFunctionCreate(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	argumentTypes := functionArgumentTypes(functionArgumentsFromList(d.Get("argument").([]interface{})))
+
+	err := meta.(*Client).ExecSchemaChange(generateCreateFunctionQueryString(d))
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s.%s", keyspace, functionSignature(name, argumentTypes)))
+
+	return resourceFunctionRead(d, meta)
+}
+
+func resourceFunctionRead(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	arguments := functionArgumentsFromList(d.Get("argument").([]interface{}))
+	argumentTypes := functionArgumentTypes(arguments)
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return sessionCreateError
+	}
+
+	function, found, err := readFunction(session, keyspace, name, argumentTypes)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("function %s does not exist in keyspace %s", functionSignature(name, argumentTypes), keyspace)
+	}
+
+	rawArguments := make([]map[string]interface{}, 0, len(function.ArgumentNames))
+
+	for index, argumentName := range function.ArgumentNames {
+		rawArguments = append(rawArguments, map[string]interface{}{
+			"name": argumentName,
+			"type": function.ArgumentTypes[index],
+		})
+	}
+
+	d.Set("argument", rawArguments)
+	d.Set("return_type", function.ReturnType)
+	d.Set("language", function.Language)
+	d.Set("called_on_null_input", function.CalledOnNullInput)
+	d.Set("body", function.Body)
+	d.Set("signature", functionSignature(name, argumentTypes))
+
+	return nil
+}
+
+func resourceFunctionUpdate(d *schema.ResourceData, meta interface{}) error {
+	err := meta.(*Client).ExecSchemaChange(generateCreateFunctionQueryString(d))
+
+	if err != nil {
+		return err
+	}
+
+	return resourceFunctionRead(d, meta)
+}
+
+func resourceFunctionDelete(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	argumentTypes := functionArgumentTypes(functionArgumentsFromList(d.Get("argument").([]interface{})))
+
+	return meta.(*Client).ExecSchemaChange(fmt.Sprintf(`DROP FUNCTION %s`, cqlFunctionSignature(keyspace, name, argumentTypes)))
+}
@@ -3,19 +3,20 @@ package main
 import (
 	"bytes"
 	"fmt"
-	"html/template"
 	"log"
 	"regexp"
+	"sort"
 	"strings"
+	"text/template"
 
 	"github.com/gocql/gocql"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
 )
 
 const (
-	deleteGrantRawTemplate = `REVOKE {{ .Privilege }} ON {{.ResourceType}} {{if .Keyspace }}"{{ .Keyspace}}"{{end}}{{if and .Keyspace .Identifier}}.{{end}}{{if .Identifier}}"{{.Identifier}}"{{end}} FROM "{{.Grantee}}"`
-	createGrantRawTemplate = `GRANT {{ .Privilege }} ON {{.ResourceType}} {{if .Keyspace }}"{{ .Keyspace}}"{{end}}{{if and .Keyspace .Identifier}}.{{end}}{{if .Identifier}}"{{.Identifier}}"{{end}} TO "{{.Grantee}}"`
-	readGrantRawTemplate   = `LIST {{ .Privilege }} ON {{.ResourceType}} {{if .Keyspace }}"{{ .Keyspace }}"{{end}}{{if and .Keyspace .Identifier}}.{{end}}{{if .Identifier}}"{{.Identifier}}"{{end}} OF "{{.Grantee}}"`
+	deleteGrantRawTemplate = `REVOKE {{ .Privilege }} ON {{.ResourceType}}{{if .Resource}} {{.Resource}}{{end}} FROM {{.QuotedGrantee}}`
+	createGrantRawTemplate = `GRANT {{ .Privilege }} ON {{.ResourceType}}{{if .Resource}} {{.Resource}}{{end}} TO {{.QuotedGrantee}}`
+	readGrantRawTemplate   = `LIST ALL PERMISSIONS ON {{.ResourceType}}{{if .Resource}} {{.Resource}}{{end}} OF {{.QuotedGrantee}} NORECURSIVE`
 
 	privilegeAll       = "all"
 	privilegeCreate    = "create"
@@ -48,7 +49,18 @@ const (
 	identifierKeyspaceName = "keyspace_name"
 	identifierGrantee      = "grantee"
 	identifierPrivilege    = "privilege"
+	identifierPrivileges   = "privileges"
 	identifierResourceType = "resource_type"
+
+	// Cassandra lists the functions of a keyspace as <all functions in ks>
+	listedAllFunctionsInKeyspacePrefix = "all functions in "
+
+	grantImportIDSeparator        = "|"
+	grantImportPrivilegeSeparator = ","
+
+	// error code of an invalid request, returned when listing the permissions
+	// of a role or on a resource which does not exist
+	cqlErrorCodeInvalid = 0x2200
 )
 
 var (
@@ -56,8 +68,9 @@ var (
 	templateCreate, _ = template.New("create_grant").Parse(createGrantRawTemplate)
 	templateRead, _   = template.New("read_grant").Parse(readGrantRawTemplate)
 
-	validIdentifierRegex, _ = regexp.Compile(`^[^"]{1,256}$`)
-	validTableNameRegex, _  = regexp.Compile(`^[a-zA-Z0-9][a-zA-Z0-9_]{0,255}$`)
+	validIdentifierRegex, _   = regexp.Compile(`^[^"]{1,256}$`)
+	validFunctionNameRegex, _ = regexp.Compile(`^[^"()]{1,256}(\((?:[a-zA-Z0-9_<>, ]|"(?:[^"]|"")+")*\))?$`)
+	validTableNameRegex, _    = regexp.Compile(`^[a-zA-Z0-9][a-zA-Z0-9_]{0,255}$`)
 
 	allPrivileges = []string{privilegeSelect, privilegeCreate, privilegeAlter, privilegeDrop, privilegeModify, privilegeAuthorize, privilegeDescribe, privilegeExecute}
 
@@ -67,10 +80,10 @@ var (
 		privilegeAll:       {resourceAllFunctions, resourceAllFunctionsInKeyspace, resourceFunction, resourceAllKeyspaces, resourceKeyspace, resourceTable, resourceAllRoles, resourceRole},
 		privilegeCreate:    {resourceAllKeyspaces, resourceKeyspace, resourceAllFunctions, resourceAllFunctionsInKeyspace, resourceAllRoles},
 		privilegeAlter:     {resourceAllKeyspaces, resourceKeyspace, resourceTable, resourceAllFunctions, resourceAllFunctionsInKeyspace, resourceFunction, resourceAllRoles, resourceRole},
-		privilegeDrop:      {resourceKeyspace, resourceTable, resourceAllFunctions, resourceAllFunctionsInKeyspace, resourceFunction, resourceAllRoles, resourceRole},
+		privilegeDrop:      {resourceAllKeyspaces, resourceKeyspace, resourceTable, resourceAllFunctions, resourceAllFunctionsInKeyspace, resourceFunction, resourceAllRoles, resourceRole},
 		privilegeSelect:    {resourceAllKeyspaces, resourceKeyspace, resourceTable, resourceAllMbeans, resourceMbeans, resourceMbean},
 		privilegeModify:    {resourceAllKeyspaces, resourceKeyspace, resourceTable, resourceAllMbeans, resourceMbeans, resourceMbean},
-		privilegeAuthorize: {resourceAllKeyspaces, resourceKeyspace, resourceTable, resourceFunction, resourceAllFunctions, resourceAllFunctionsInKeyspace, resourceAllRoles, resourceRoles},
+		privilegeAuthorize: {resourceAllKeyspaces, resourceKeyspace, resourceTable, resourceFunction, resourceAllFunctions, resourceAllFunctionsInKeyspace, resourceAllRoles, resourceRole, resourceRoles},
 		privilegeDescribe:  {resourceAllRoles, resourceAllMbeans},
 		privilegeExecute:   {resourceAllFunctions, resourceAllFunctionsInKeyspace, resourceFunction},
 	}
@@ -110,6 +123,74 @@ type Grant struct {
 	Identifier   string
 }
 
+// QuotedIdentifier returns the identifier quoted for use in CQL, a function
+// signature such as my_function(int, text) keeps its argument types unquoted
+func (grant *Grant) QuotedIdentifier() string {
+	if grant.ResourceType == resourceFunction {
+		if index := strings.Index(grant.Identifier, "("); index > 0 {
+			return cqlIdentifier(grant.Identifier[:index]) + grant.Identifier[index:]
+		}
+	}
+
+	return cqlIdentifier(grant.Identifier)
+}
+
+// Resource returns the name of the resource the grant is on for use in CQL,
+// mbeans are named by string literals rather than identifiers
+func (grant *Grant) Resource() string {
+	switch {
+	case grant.ResourceType == resourceMbean || grant.ResourceType == resourceMbeans:
+		return cqlString(grant.Identifier)
+	case grant.Keyspace != "" && grant.Identifier != "":
+		return cqlIdentifier(grant.Keyspace) + "." + grant.QuotedIdentifier()
+	case grant.Keyspace != "":
+		return cqlIdentifier(grant.Keyspace)
+	case grant.Identifier != "":
+		return grant.QuotedIdentifier()
+	}
+
+	return ""
+}
+
+// QuotedGrantee returns the grantee quoted for use in CQL
+func (grant *Grant) QuotedGrantee() string {
+	return cqlIdentifier(grant.Grantee)
+}
+
+// ImportID returns the ID used to import the grant, in the form
+// privilege|resource_type|keyspace_name|identifier|grantee
+func (grant *Grant) ImportID() string {
+	return strings.Join([]string{grant.Privilege, grant.ResourceType, grant.Keyspace, grant.Identifier, grant.Grantee}, grantImportIDSeparator)
+}
+
+func parseGrantImportID(id string) (*Grant, error) {
+	parts := strings.SplitN(id, grantImportIDSeparator, 5)
+
+	if len(parts) != 5 {
+		return nil, fmt.Errorf("%s: invalid import id - must be of the form privilege|resource_type|keyspace_name|identifier|grantee", id)
+	}
+
+	resourceType := parts[1]
+	identifier := parts[3]
+
+	// names are stored without their quotes, as the configured ones are
+	if identifierKey := resourceTypeToIdentifier[resourceType]; identifierKey == identifierTableName || identifierKey == identifierRoleName {
+		identifier = unquoteIdentifier(identifier)
+	}
+
+	return &Grant{parts[0], resourceType, unquoteIdentifier(parts[4]), unquoteIdentifier(parts[2]), identifier}, nil
+}
+
+func validPrivilege(i interface{}, s string) (ws []string, errors []error) {
+	privilege := i.(string)
+
+	if len(privilegeToResourceTypesMap[privilege]) <= 0 {
+		errors = append(errors, fmt.Errorf("%s not one of %s", privilege, strings.Join(allPrivileges, ", ")))
+	}
+
+	return
+}
+
 func validIdentifier(i interface{}, s string, identifierName string, regularExpression *regexp.Regexp) (ws []string, errors []error) {
 	identifier := i.(string)
 
@@ -120,6 +201,12 @@ func validIdentifier(i interface{}, s string, identifierName string, regularExpr
 	return
 }
 
+// validQuotedIdentifier validates a name which may be written with the double
+// quotes of a CQL quoted identifier
+func validQuotedIdentifier(i interface{}, s string, identifierName string, regularExpression *regexp.Regexp) (ws []string, errors []error) {
+	return validIdentifier(unquoteIdentifier(i.(string)), s, identifierName, regularExpression)
+}
+
 func resourceCassandraGrant() *schema.Resource {
 	return &schema.Resource{
 		Create: resourceGrantCreate,
@@ -127,31 +214,40 @@ func resourceCassandraGrant() *schema.Resource {
 		Update: resourceGrantUpdate,
 		Delete: resourceGrantDelete,
 		Exists: resourceGrantExists,
+		Importer: &schema.ResourceImporter{
+			State: resourceGrantImport,
+		},
+		CustomizeDiff: resourceGrantCustomizeDiff,
 		Schema: map[string]*schema.Schema{
 			identifierPrivilege: &schema.Schema{
-				Type:        schema.TypeString,
-				Required:    true,
-				ForceNew:    true,
-				Description: fmt.Sprintf("One of %s", strings.Join(allPrivileges, ", ")),
-
-				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
-					privilege := i.(string)
-
-					if len(privilegeToResourceTypesMap[privilege]) <= 0 {
-						errors = append(errors, fmt.Errorf("%s not one of %s", privilege, strings.Join(allPrivileges, ", ")))
-					}
-
-					return
+				Type:          schema.TypeString,
+				Optional:      true,
+				ForceNew:      true,
+				Description:   fmt.Sprintf("One of %s", strings.Join(allPrivileges, ", ")),
+				ValidateFunc:  validPrivilege,
+				ConflictsWith: []string{identifierPrivileges},
+			},
+			identifierPrivileges: &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: fmt.Sprintf("Set of privileges granted on the resource, each one of %s - changes only grant or revoke the privileges added or removed", strings.Join(allPrivileges, ", ")),
+				Set:         schema.HashString,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validPrivilege,
 				},
+				ConflictsWith: []string{identifierPrivilege},
 			},
+			identifierConsistency: authConsistencySchema(),
 			identifierGrantee: &schema.Schema{
 				Type:        schema.TypeString,
 				Required:    true,
 				ForceNew:    true,
 				Description: "role name who we are granting privilege(s) to",
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
-					return validIdentifier(i, s, "grantee", validRoleRegex)
+					return validQuotedIdentifier(i, s, "grantee", validRoleRegex)
 				},
+				StateFunc: identifierStateFunc,
 			},
 			identifierResourceType: &schema.Schema{
 				Type:        schema.TypeString,
@@ -174,7 +270,7 @@ func resourceCassandraGrant() *schema.Resource {
 				ForceNew:    true,
 				Description: fmt.Sprintf("keyspace qualifier to the resource, only applicable for resource %s", strings.Join(resourcesThatRequireKeyspaceQualifier, ", ")),
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
-					keyspaceName := i.(string)
+					keyspaceName := unquoteIdentifier(i.(string))
 
 					if !keyspaceRegex.MatchString(keyspaceName) {
 						errors = append(errors, fmt.Errorf("%s in not a valid keyspace name", keyspaceName))
@@ -182,14 +278,16 @@ func resourceCassandraGrant() *schema.Resource {
 
 					return
 				},
+				StateFunc:     identifierStateFunc,
 				ConflictsWith: []string{identifierRoleName, identifierMbeanName, identifierMbeanPattern},
 			},
 			identifierFunctionName: &schema.Schema{
 				Type:        schema.TypeString,
 				Optional:    true,
-				Description: fmt.Sprintf("keyspace qualifier to the resource, only applicable for resource %s", strings.Join(resourcesThatRequireKeyspaceQualifier, ", ")),
+				ForceNew:    true,
+				Description: fmt.Sprintf("name or signature e.g. my_function(int, text) of the function, applicable only for resource %s", resourceFunction),
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
-					return validIdentifier(i, s, "function name", validIdentifierRegex)
+					return validIdentifier(i, s, "function name", validFunctionNameRegex)
 				},
 				ConflictsWith: []string{identifierTableName, identifierRoleName, identifierMbeanName, identifierMbeanPattern},
 			},
@@ -199,8 +297,9 @@ func resourceCassandraGrant() *schema.Resource {
 				ForceNew:    true,
 				Description: fmt.Sprintf("name of the table, applicable only for resource %s", resourceTable),
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
-					return validIdentifier(i, s, "table name", validTableNameRegex)
+					return validQuotedIdentifier(i, s, "table name", validTableNameRegex)
 				},
+				StateFunc:     identifierStateFunc,
 				ConflictsWith: []string{identifierFunctionName, identifierRoleName, identifierMbeanName, identifierMbeanPattern},
 			},
 			identifierRoleName: &schema.Schema{
@@ -209,8 +308,9 @@ func resourceCassandraGrant() *schema.Resource {
 				ForceNew:    true,
 				Description: fmt.Sprintf("name of the role, applicable only for resource %s", resourceRole),
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
-					return validIdentifier(i, s, "role name", validRoleRegex)
+					return validQuotedIdentifier(i, s, "role name", validRoleRegex)
 				},
+				StateFunc:     identifierStateFunc,
 				ConflictsWith: []string{identifierFunctionName, identifierTableName, identifierMbeanName, identifierMbeanPattern, identifierKeyspaceName},
 			},
 			identifierMbeanName: &schema.Schema{
@@ -245,9 +345,26 @@ func resourceCassandraGrant() *schema.Resource {
 	}
 }
 
-func parseData(d *schema.ResourceData) (*Grant, error) {
-	privilege := d.Get(identifierPrivilege).(string)
-	grantee := d.Get(identifierGrantee).(string)
+// grantPrivileges returns the privileges of the resource, from either the
+// privilege or the privileges attribute
+func grantPrivileges(d resourceGetter) []string {
+	if privilege := d.Get(identifierPrivilege).(string); privilege != "" {
+		return []string{privilege}
+	}
+
+	var privileges []string
+
+	for _, privilege := range d.Get(identifierPrivileges).(*schema.Set).List() {
+		privileges = append(privileges, privilege.(string))
+	}
+
+	sort.Strings(privileges)
+
+	return privileges
+}
+
+func parseGrant(d resourceGetter, privilege string) (*Grant, error) {
+	grantee := getIdentifier(d, identifierGrantee)
 	resourceType := d.Get(identifierResourceType).(string)
 
 	allowedResouceTypesForPrivilege := privilegeToResourceTypesMap[privilege]
@@ -279,7 +396,7 @@ func parseData(d *schema.ResourceData) (*Grant, error) {
 	var keyspaceName = ""
 
 	if requiresKeyspaceQualifier {
-		keyspaceName = d.Get(identifierKeyspaceName).(string)
+		keyspaceName = getIdentifier(d, identifierKeyspaceName)
 
 		if keyspaceName == "" {
 			return nil, fmt.Errorf("keyspace name must be set for resourceType %s", resourceType)
@@ -293,6 +410,10 @@ func parseData(d *schema.ResourceData) (*Grant, error) {
 	if identifierKey != "" {
 		identifier = d.Get(identifierKey).(string)
 
+		if identifierKey == identifierTableName || identifierKey == identifierRoleName {
+			identifier = unquoteIdentifier(identifier)
+		}
+
 		if identifier == "" {
 			return nil, fmt.Errorf("%s needs to be set when resourceType = %s", identifierKey, resourceType)
 		}
@@ -301,95 +422,249 @@ func parseData(d *schema.ResourceData) (*Grant, error) {
 	return &Grant{privilege, resourceType, grantee, keyspaceName, identifier}, nil
 }
 
-func resourceGrantExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
-	grant, err := parseData(d)
+// parseData returns a grant for every privilege of the resource, each one
+// validated against the resource type
+func parseData(d resourceGetter) ([]*Grant, error) {
+	privileges := grantPrivileges(d)
 
-	if err != nil {
-		return false, err
+	if len(privileges) == 0 {
+		return nil, fmt.Errorf("one of %s or %s must be set", identifierPrivilege, identifierPrivileges)
 	}
 
-	cluster := meta.(*gocql.ClusterConfig)
+	grants := make([]*Grant, 0, len(privileges))
 
-	session, sessionCreationError := cluster.CreateSession()
+	for _, privilege := range privileges {
+		grant, err := parseGrant(d, privilege)
 
-	if sessionCreationError != nil {
-		return false, sessionCreationError
+		if err != nil {
+			return nil, err
+		}
+
+		grants = append(grants, grant)
 	}
 
-	defer session.Close()
+	return grants, nil
+}
+
+// grantID returns the ID of the resource, which does not change with the
+// privileges when they are managed as a set
+func grantID(d *schema.ResourceData, grant *Grant) string {
+	if d.Get(identifierPrivilege).(string) != "" {
+		return hash(fmt.Sprintf("%+v", grant))
+	}
+
+	resourceGrant := *grant
+	resourceGrant.Privilege = ""
 
+	return hash(fmt.Sprintf("%+v", &resourceGrant))
+}
+
+func executeGrantTemplate(meta interface{}, grantTemplate *template.Template, grant *Grant) error {
 	var buffer bytes.Buffer
-	templateRenderError := templateRead.Execute(&buffer, grant)
+
+	templateRenderError := grantTemplate.Execute(&buffer, grant)
 
 	if templateRenderError != nil {
-		return false, templateRenderError
+		return templateRenderError
 	}
 
 	query := buffer.String()
 
-	iter := session.Query(query).Iter()
+	log.Printf("Executing query %v", query)
+
+	return meta.(*Client).ExecStatement(query)
+}
+
+// parseListedResource converts a resource as printed by LIST PERMISSIONS e.g.
+// <table keyspace.table> into a permission without privileges
+func parseListedResource(resource string) (*RolePermission, bool) {
+	resource = strings.TrimSuffix(strings.TrimPrefix(resource, "<"), ">")
+
+	for _, resourceType := range []string{resourceAllKeyspaces, resourceAllRoles, resourceAllMbeans, resourceAllFunctions} {
+		if resource == resourceType {
+			return &RolePermission{ResourceType: resourceType}, true
+		}
+	}
 
-	rowCount := iter.NumRows()
+	parts := strings.SplitN(resource, " ", 2)
+
+	if len(parts) != 2 {
+		return nil, false
+	}
 
-	iterError := iter.Close()
+	switch {
+	case strings.HasPrefix(resource, listedAllFunctionsInKeyspacePrefix):
+		return &RolePermission{ResourceType: resourceAllFunctionsInKeyspace, Keyspace: strings.TrimPrefix(resource, listedAllFunctionsInKeyspacePrefix)}, true
+	case parts[0] == resourceKeyspace:
+		return &RolePermission{ResourceType: resourceKeyspace, Keyspace: parts[1]}, true
+	case parts[0] == resourceRole:
+		return &RolePermission{ResourceType: resourceRole, Identifier: parts[1]}, true
+	case parts[0] == resourceMbean:
+		return &RolePermission{ResourceType: resourceMbean, Identifier: parts[1]}, true
+	case parts[0] == resourceTable || parts[0] == resourceFunction:
+		qualified := strings.SplitN(parts[1], ".", 2)
+
+		if len(qualified) != 2 {
+			return nil, false
+		}
+
+		return &RolePermission{ResourceType: parts[0], Keyspace: qualified[0], Identifier: qualified[1]}, true
+	}
 
-	return rowCount > 0, iterError
+	return nil, false
 }
 
-func resourceGrantCreate(d *schema.ResourceData, meta interface{}) error {
-	grant, err := parseData(d)
+// grantOnResource reports whether a listed permission is on the resource of
+// the grant, a function referenced without its signature matches any overload
+func grantOnResource(grant *Grant, listed *RolePermission) bool {
+	expected := &RolePermission{ResourceType: grant.ResourceType, Keyspace: grant.Keyspace, Identifier: grant.Identifier}
 
-	if err != nil {
-		return err
+	if expected.Key() == listed.Key() {
+		return true
 	}
 
-	cluster := meta.(*gocql.ClusterConfig)
+	return grant.ResourceType == resourceFunction && listed.ResourceType == resourceFunction &&
+		grant.Keyspace == listed.Keyspace && !strings.Contains(grant.Identifier, "(") &&
+		strings.HasPrefix(listed.Identifier, grant.Identifier+"(")
+}
 
-	session, sessionCreationError := cluster.CreateSession()
+// listGrantedPrivileges returns the privileges granted directly to the grantee
+// on the resource of the grant, parsed from the rows of LIST PERMISSIONS as it
+// also returns permissions on parent resources. found is false when the
+// grantee or the resource no longer exist.
+func listGrantedPrivileges(meta interface{}, grant *Grant, consistency gocql.Consistency) (privileges []string, found bool, err error) {
+	session, sessionCreationError := meta.(*Client).Session()
 
 	if sessionCreationError != nil {
-		return sessionCreationError
+		return nil, false, sessionCreationError
 	}
 
-	defer session.Close()
-
 	var buffer bytes.Buffer
-
-	templateRenderError := templateCreate.Execute(&buffer, grant)
+	templateRenderError := templateRead.Execute(&buffer, grant)
 
 	if templateRenderError != nil {
-		return templateRenderError
+		return nil, false, templateRenderError
 	}
 
 	query := buffer.String()
 
-	log.Printf("Executing query %v", query)
+	log.Println("query", query)
+
+	iter := session.Query(query).Consistency(consistency).Iter()
+
+	row := make(map[string]interface{})
 
-	d.SetId(hash(fmt.Sprintf("%+v", grant)))
+	for iter.MapScan(row) {
+		role, _ := row["role"].(string)
+		resource, _ := row["resource"].(string)
+		permission, _ := row["permission"].(string)
+
+		row = make(map[string]interface{})
+
+		if role != grant.Grantee {
+			continue
+		}
 
-	return session.Query(query).Exec()
+		listed, ok := parseListedResource(resource)
+
+		if !ok || !grantOnResource(grant, listed) {
+			continue
+		}
+
+		privileges = append(privileges, strings.ToLower(permission))
+	}
+
+	if err := iter.Close(); err != nil {
+		if requestError, ok := err.(gocql.RequestError); ok && requestError.Code() == cqlErrorCodeInvalid {
+			log.Printf("Unable to list permissions of %s: %v", grant.Grantee, err)
+
+			return nil, false, nil
+		}
+
+		return nil, false, err
+	}
+
+	sort.Strings(privileges)
+
+	return privileges, true, nil
 }
 
-func resourceGrantRead(d *schema.ResourceData, meta interface{}) error {
-	exists, err := resourceGrantExists(d, meta)
+// grantedPrivileges returns the privileges of the resource which are granted
+// on the cluster, all is returned when every privilege it expands to is granted
+func grantedPrivileges(d *schema.ResourceData, meta interface{}) ([]string, error) {
+	grants, err := parseData(d)
 
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	if !exists {
-		return fmt.Errorf("Grant does not exist")
+	listed, found, err := listGrantedPrivileges(meta, grants[0], authConsistency(d))
+
+	if err != nil || !found {
+		return nil, err
+	}
+
+	return collapseAllPrivileges(grants, listed), nil
+}
+
+// collapseAllPrivileges returns the listed privileges with the ones all expands
+// to on the resource replaced by all, when all is configured and every one of
+// them is granted
+func collapseAllPrivileges(grants []*Grant, listed []string) []string {
+	granted := make(map[string]bool)
+
+	for _, privilege := range listed {
+		granted[privilege] = true
+	}
+
+	var privileges []string
+
+	for _, grant := range grants {
+		if grant.Privilege != privilegeAll {
+			continue
+		}
+
+		all := true
+
+		for _, privilege := range applicablePrivileges(grant.ResourceType) {
+			all = all && granted[privilege]
+		}
+
+		if all {
+			for _, privilege := range applicablePrivileges(grant.ResourceType) {
+				delete(granted, privilege)
+			}
+
+			privileges = append(privileges, privilegeAll)
+		}
+	}
+
+	for privilege := range granted {
+		privileges = append(privileges, privilege)
 	}
 
-	grant, err := parseData(d)
+	sort.Strings(privileges)
+
+	return privileges
+}
+
+func resourceGrantImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	grant, err := parseGrantImportID(d.Id())
 
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	privileges := strings.Split(grant.Privilege, grantImportPrivilegeSeparator)
+
+	if len(privileges) > 1 {
+		d.Set(identifierPrivileges, privileges)
+	} else {
+		d.Set(identifierPrivilege, grant.Privilege)
 	}
 
 	d.Set(identifierResourceType, grant.ResourceType)
 	d.Set(identifierGrantee, grant.Grantee)
-	d.Set(identifierPrivilege, grant.Privilege)
 
 	if grant.Keyspace != "" {
 		d.Set(identifierKeyspaceName, grant.Keyspace)
@@ -398,42 +673,191 @@ func resourceGrantRead(d *schema.ResourceData, meta interface{}) error {
 	if grant.Identifier != "" {
 		identifierName := resourceTypeToIdentifier[grant.ResourceType]
 
+		if identifierName == "" {
+			return nil, fmt.Errorf("resourceType %s does not take an identifier", grant.ResourceType)
+		}
+
 		d.Set(identifierName, grant.Identifier)
 	}
 
-	return nil
+	parsedGrants, err := parseData(d)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, parsedGrant := range parsedGrants {
+		expectedGrant := *grant
+		expectedGrant.Privilege = parsedGrant.Privilege
+
+		if parsedGrant.ImportID() != expectedGrant.ImportID() {
+			return nil, fmt.Errorf("%s: invalid import id - expected %s", expectedGrant.ImportID(), parsedGrant.ImportID())
+		}
+	}
+
+	d.SetId(grantID(d, parsedGrants[0]))
+
+	return []*schema.ResourceData{d}, nil
 }
 
-func resourceGrantDelete(d *schema.ResourceData, meta interface{}) error {
-	grant, err := parseData(d)
+func resourceGrantExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	privileges, err := grantedPrivileges(d, meta)
+
+	if err != nil {
+		return false, err
+	}
+
+	for _, privilege := range privileges {
+		for _, configured := range grantPrivileges(d) {
+			if privilege == configured {
+				return true, nil
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func resourceGrantCreate(d *schema.ResourceData, meta interface{}) error {
+	grants, err := parseData(d)
 
 	if err != nil {
 		return err
 	}
 
-	var buffer bytes.Buffer
+	for _, grant := range grants {
+		if err := executeGrantTemplate(meta, templateCreate, grant); err != nil {
+			return err
+		}
+	}
+
+	d.SetId(grantID(d, grants[0]))
+
+	return nil
+}
 
-	err = templateDelete.Execute(&buffer, grant)
+// resourceGrantRead sets the privileges actually granted on the resource. A
+// single privilege which was revoked, or a set of which nothing is left,
+// clears the ID so the grant is planned again.
+func resourceGrantRead(d *schema.ResourceData, meta interface{}) error {
+	privileges, err := grantedPrivileges(d, meta)
 
 	if err != nil {
 		return err
 	}
 
-	cluster := meta.(*gocql.ClusterConfig)
+	if privilege := d.Get(identifierPrivilege).(string); privilege != "" {
+		for _, granted := range privileges {
+			if granted == privilege {
+				return nil
+			}
+		}
+
+		log.Printf("[WARN] Grant of %s on %s to %s no longer exists, removing it from state", privilege, d.Get(identifierResourceType).(string), d.Get(identifierGrantee).(string))
+
+		d.SetId("")
+
+		return nil
+	}
+
+	if len(privileges) == 0 {
+		log.Printf("[WARN] Grants on %s to %s no longer exist, removing them from state", d.Get(identifierResourceType).(string), d.Get(identifierGrantee).(string))
+
+		d.SetId("")
+
+		return nil
+	}
+
+	d.Set(identifierPrivileges, privileges)
+
+	return nil
+}
 
-	session, err := cluster.CreateSession()
+func resourceGrantDelete(d *schema.ResourceData, meta interface{}) error {
+	grants, err := parseData(d)
 
 	if err != nil {
 		return err
 	}
 
-	query := buffer.String()
+	for _, grant := range grants {
+		if err := executeGrantTemplate(meta, templateDelete, grant); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
 
-	defer session.Close()
+// resourceGrantCustomizeDiff fails the plan when a privilege is not applicable
+// to the resource type or the resource is not fully qualified, so that an
+// update does not stop halfway through its grants and revokes
+func resourceGrantCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	// values interpolated from other resources are only known at apply
+	for _, key := range []string{identifierPrivilege, identifierPrivileges, identifierResourceType, identifierKeyspaceName, identifierFunctionName, identifierTableName, identifierRoleName, identifierMbeanName, identifierMbeanPattern} {
+		if !d.NewValueKnown(key) {
+			return nil
+		}
+	}
 
-	return session.Query(query).Exec()
+	_, err := parseData(d)
+
+	return err
 }
 
+// resourceGrantUpdate only revokes the privileges removed from the set and
+// grants those added, every other attribute forces a new resource. Every
+// privilege is parsed before the first statement is executed.
 func resourceGrantUpdate(d *schema.ResourceData, meta interface{}) error {
-	return fmt.Errorf("Updating of grants is not supported")
+	if !d.HasChange(identifierPrivileges) {
+		return nil
+	}
+
+	oldRaw, newRaw := d.GetChange(identifierPrivileges)
+
+	oldPrivileges := oldRaw.(*schema.Set)
+	newPrivileges := newRaw.(*schema.Set)
+
+	revoked, err := parseGrants(d, oldPrivileges.Difference(newPrivileges))
+
+	if err != nil {
+		return err
+	}
+
+	granted, err := parseGrants(d, newPrivileges.Difference(oldPrivileges))
+
+	if err != nil {
+		return err
+	}
+
+	for _, grant := range revoked {
+		if err := executeGrantTemplate(meta, templateDelete, grant); err != nil {
+			return err
+		}
+	}
+
+	for _, grant := range granted {
+		if err := executeGrantTemplate(meta, templateCreate, grant); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// parseGrants returns a grant for every privilege of the set
+func parseGrants(d resourceGetter, privileges *schema.Set) ([]*Grant, error) {
+	grants := make([]*Grant, 0, privileges.Len())
+
+	for _, privilege := range privileges.List() {
+		grant, err := parseGrant(d, privilege.(string))
+
+		if err != nil {
+			return nil, err
+		}
+
+		grants = append(grants, grant)
+	}
+
+	return grants, nil
 }
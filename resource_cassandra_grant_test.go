@@ -0,0 +1,139 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func TestParseListedResource(t *testing.T) {
+	cases := []struct {
+		listed   string
+		expected *RolePermission
+	}{
+		{"<all keyspaces>", &RolePermission{ResourceType: resourceAllKeyspaces}},
+		{"<keyspace ks>", &RolePermission{ResourceType: resourceKeyspace, Keyspace: "ks"}},
+		{"<keyspace MyKs>", &RolePermission{ResourceType: resourceKeyspace, Keyspace: "MyKs"}},
+		{"<table ks.events>", &RolePermission{ResourceType: resourceTable, Keyspace: "ks", Identifier: "events"}},
+		{"<all functions>", &RolePermission{ResourceType: resourceAllFunctions}},
+		{"<all functions in ks>", &RolePermission{ResourceType: resourceAllFunctionsInKeyspace, Keyspace: "ks"}},
+		{"<function ks.total(int, text)>", &RolePermission{ResourceType: resourceFunction, Keyspace: "ks", Identifier: "total(int, text)"}},
+		{"<function ks.now()>", &RolePermission{ResourceType: resourceFunction, Keyspace: "ks", Identifier: "now()"}},
+		{"<all roles>", &RolePermission{ResourceType: resourceAllRoles}},
+		{"<role app user>", &RolePermission{ResourceType: resourceRole, Identifier: "app user"}},
+		{"<all mbeans>", &RolePermission{ResourceType: resourceAllMbeans}},
+		{"<mbean org.apache.cassandra.db:type=Tables,*>", &RolePermission{ResourceType: resourceMbean, Identifier: "org.apache.cassandra.db:type=Tables,*"}},
+	}
+
+	for _, c := range cases {
+		actual, ok := parseListedResource(c.listed)
+
+		if !ok {
+			t.Errorf("%s: not parsed", c.listed)
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %+v, got %+v", c.listed, c.expected, actual)
+		}
+	}
+}
+
+func TestParseListedResourceUnknown(t *testing.T) {
+	for _, listed := range []string{"<data>", "<table events>", "<unknown ks>"} {
+		if permission, ok := parseListedResource(listed); ok {
+			t.Errorf("%s: expected no match, got %+v", listed, permission)
+		}
+	}
+}
+
+func TestCollapseAllPrivileges(t *testing.T) {
+	cases := []struct {
+		resourceType string
+		configured   []string
+		listed       []string
+		expected     []string
+	}{
+		{resourceAllKeyspaces, []string{privilegeAll}, []string{"alter", "authorize", "create", "drop", "modify", "select"}, []string{privilegeAll}},
+		{resourceRole, []string{privilegeAll}, []string{"alter", "authorize", "drop"}, []string{privilegeAll}},
+		{resourceTable, []string{privilegeAll}, []string{"alter", "drop", "modify", "select"}, []string{"alter", "drop", "modify", "select"}},
+		{resourceTable, []string{privilegeSelect}, []string{"alter", "authorize", "drop", "modify", "select"}, []string{"alter", "authorize", "drop", "modify", "select"}},
+		{resourceFunction, []string{privilegeAll}, []string{"alter", "authorize", "drop", "execute"}, []string{privilegeAll}},
+	}
+
+	for _, c := range cases {
+		grants := make([]*Grant, 0, len(c.configured))
+
+		for _, privilege := range c.configured {
+			grants = append(grants, &Grant{Privilege: privilege, ResourceType: c.resourceType, Grantee: "app"})
+		}
+
+		if actual := collapseAllPrivileges(grants, c.listed); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s %v: expected %v, got %v", c.resourceType, c.configured, c.expected, actual)
+		}
+	}
+}
+
+// testResource returns the values of a resource by key
+type testResource map[string]interface{}
+
+func (r testResource) Get(key string) interface{} {
+	if value, ok := r[key]; ok {
+		return value
+	}
+
+	return ""
+}
+
+func TestParseDataInapplicablePrivilege(t *testing.T) {
+	resource := testResource{
+		identifierPrivileges:   schema.NewSet(schema.HashString, []interface{}{privilegeSelect, privilegeExecute}),
+		identifierResourceType: resourceTable,
+		identifierGrantee:      "app",
+		identifierKeyspaceName: "ks",
+		identifierTableName:    "events",
+	}
+
+	if _, err := parseData(resource); err == nil {
+		t.Error("expected execute on a table to be rejected")
+	}
+
+	resource[identifierPrivileges] = schema.NewSet(schema.HashString, []interface{}{privilegeSelect, privilegeModify})
+
+	grants, err := parseData(resource)
+
+	if err != nil {
+		t.Fatalf("expected select and modify on a table to be accepted, got %v", err)
+	}
+
+	if len(grants) != 2 {
+		t.Errorf("expected a grant per privilege, got %d", len(grants))
+	}
+}
+
+func TestParseGrantImportID(t *testing.T) {
+	cases := []struct {
+		id       string
+		expected *Grant
+	}{
+		{`select|table|ks|events|app`, &Grant{privilegeSelect, resourceTable, "app", "ks", "events"}},
+		{`select|table|"MyKs"|"Events"|app`, &Grant{privilegeSelect, resourceTable, "app", "MyKs", "Events"}},
+		{`alter|role||"App ""Admin"""|"Ops"`, &Grant{privilegeAlter, resourceRole, "Ops", "", `App "Admin"`}},
+		{`execute|function|ks|format(frozen<"Address Book">)|app`, &Grant{privilegeExecute, resourceFunction, "app", "ks", `format(frozen<"Address Book">)`}},
+	}
+
+	for _, c := range cases {
+		actual, err := parseGrantImportID(c.id)
+
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", c.id, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("%s: expected %+v, got %+v", c.id, c.expected, actual)
+		}
+	}
+}
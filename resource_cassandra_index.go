@@ -0,0 +1,266 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const (
+	indexTargetRegular = "regular"
+	indexTargetKeys    = "keys"
+	indexTargetValues  = "values"
+	indexTargetEntries = "entries"
+	indexTargetFull    = "full"
+
+	indexOptionTarget    = "target"
+	indexOptionClassName = "class_name"
+)
+
+var (
+	indexTargetTypes    = []string{indexTargetRegular, indexTargetKeys, indexTargetValues, indexTargetEntries, indexTargetFull}
+	indexTargetRegex, _ = regexp.Compile(`^(keys|values|entries|full)\((.+)\)$`)
+)
+
+func resourceCassandraIndex() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceIndexCreate,
+		Read:          resourceIndexRead,
+		Delete:        resourceIndexDelete,
+		Exists:        resourceIndexExists,
+		CustomizeDiff: resourceIndexCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"keyspace": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the keyspace the indexed table belongs to",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "keyspace", keyspaceRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"table": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the indexed table",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "table name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the index, unique within the keyspace",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "index name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"column": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the indexed column",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "column name", columnNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"target_type": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Computed:    true,
+				ForceNew:    true,
+				Description: "Part of the column which is indexed - one of regular, keys, values, entries or full, collections default to values",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					targetType := i.(string)
+
+					for _, validTargetType := range indexTargetTypes {
+						if targetType == validTargetType {
+							return
+						}
+					}
+
+					errors = append(errors, fmt.Errorf("%s: invalid index target type - must be one of %s", targetType, strings.Join(indexTargetTypes, ", ")))
+
+					return
+				},
+			},
+			"class_name": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Class of a custom index e.g. org.apache.cassandra.index.sasi.SASIIndex",
+			},
+			"options": &schema.Schema{
+				Type:        schema.TypeMap,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Options passed to a custom index",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+		},
+	}
+}
+
+// Index represents a Cassandra secondary index as stored in system_schema.indexes
+type Index struct {
+	Column     string
+	TargetType string
+	ClassName  string
+	Options    map[string]string
+}
+
+// parseIndexTarget splits the target option of an index into the column and
+// the part of it which is indexed, regular indexes store the bare column name.
+// Cassandra quotes column names which are not lower case
+func parseIndexTarget(target string) (string, string) {
+	if matches := indexTargetRegex.FindStringSubmatch(target); matches != nil {
+		return unquoteIdentifier(matches[2]), matches[1]
+	}
+
+	return unquoteIdentifier(target), indexTargetRegular
+}
+
+func readIndex(session *gocql.Session, keyspace string, table string, name string) (*Index, bool, error) {
+	var options map[string]string
+
+	index := &Index{}
+
+	iter := session.Query(`SELECT options FROM system_schema.indexes WHERE keyspace_name = ? AND table_name = ? AND index_name = ?`, keyspace, table, name).Iter()
+
+	found := iter.Scan(&options)
+
+	if err := iter.Close(); err != nil {
+		return nil, false, err
+	}
+
+	index.Column, index.TargetType = parseIndexTarget(options[indexOptionTarget])
+	index.ClassName = options[indexOptionClassName]
+	index.Options = make(map[string]string)
+
+	for key, value := range options {
+		if key != indexOptionTarget && key != indexOptionClassName {
+			index.Options[key] = value
+		}
+	}
+
+	return index, found, nil
+}
+
+func resourceIndexCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if len(d.Get("options").(map[string]interface{})) > 0 && d.Get("class_name").(string) == "" {
+		return fmt.Errorf("options can only be set on custom indexes, class_name must be set")
+	}
+
+	return nil
+}
+
+func generateCreateIndexQueryString(d *schema.ResourceData) string {
+	column := getIdentifier(d, "column")
+	className := d.Get("class_name").(string)
+	options := d.Get("options").(map[string]interface{})
+
+	target := cqlIdentifier(column)
+
+	if targetType := d.Get("target_type").(string); targetType != "" && targetType != indexTargetRegular {
+		target = fmt.Sprintf("%s(%s)", targetType, target)
+	}
+
+	custom := ""
+
+	if className != "" {
+		custom = "CUSTOM "
+	}
+
+	query := fmt.Sprintf(`CREATE %sINDEX %s ON %s (%s)`, custom, cqlIdentifier(getIdentifier(d, "name")), cqlQualifiedName(getIdentifier(d, "keyspace"), getIdentifier(d, "table")), target)
+
+	if className != "" {
+		query += fmt.Sprintf(` USING %s`, cqlString(className))
+	}
+
+	if len(options) > 0 {
+		query += fmt.Sprintf(` WITH OPTIONS = %s`, generateTableOptionMap(options))
+	}
+
+	log.Println("query", query)
+
+	return query
+}
+
+func resourceIndexExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	keyspace := getIdentifier(d, "keyspace")
+	table := getIdentifier(d, "table")
+	name := getIdentifier(d, "name")
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return false, sessionCreateError
+	}
+
+	_, found, err := readIndex(session, keyspace, table, name)
+
+	return found, err
+}
+
+func resourceIndexCreate(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	err := meta.(*Client).ExecSchemaChange(generateCreateIndexQueryString(d))
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s.%s", keyspace, name))
+
+	return resourceIndexRead(d, meta)
+}
+
+func resourceIndexRead(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	table := getIdentifier(d, "table")
+	name := getIdentifier(d, "name")
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return sessionCreateError
+	}
+
+	index, found, err := readIndex(session, keyspace, table, name)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("index %s does not exist on table %s.%s", name, keyspace, table)
+	}
+
+	d.Set("column", index.Column)
+	d.Set("target_type", index.TargetType)
+	d.Set("class_name", index.ClassName)
+	d.Set("options", index.Options)
+
+	return nil
+}
+
+func resourceIndexDelete(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	return meta.(*Client).ExecSchemaChange(fmt.Sprintf(`DROP INDEX %s`, cqlQualifiedName(keyspace, name)))
+}
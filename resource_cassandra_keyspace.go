@@ -5,8 +5,8 @@ import (
 	"log"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
-	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
@@ -15,8 +15,19 @@ import (
 const (
 	keyspaceliteralPattern = `^[a-zA-Z0-9][a-zA-Z0-9_]{0,48}$`
 	strategyLiteralPatten  = `^SimpleStrategy|NetworkTopologyStrategy$`
+
+	simpleStrategy          = "SimpleStrategy"
+	networkTopologyStrategy = "NetworkTopologyStrategy"
+	// option of SimpleStrategy, NetworkTopologyStrategy also accepts it as the
+	// replication factor of every datacenter
+	replicationFactorOption = "replication_factor"
 )
 
+// resourceGetter is implemented by schema.ResourceData and schema.ResourceDiff
+type resourceGetter interface {
+	Get(key string) interface{}
+}
+
 var (
 	keyspaceRegex, _ = regexp.Compile(keyspaceliteralPattern)
 	strategyRegex, _ = regexp.Compile(strategyLiteralPatten)
@@ -28,11 +39,23 @@ var (
 
 func resourceCassandraKeyspace() *schema.Resource {
 	return &schema.Resource{
-		Create: resourceKeyspaceCreate,
-		Read:   resourceKeyspaceRead,
-		Update: resourceKeyspaceUpdate,
-		Delete: resourceKeyspaceDelete,
-		Exists: resourceKeyspaceExists,
+		Create:        resourceKeyspaceCreate,
+		Read:          resourceKeyspaceRead,
+		Update:        resourceKeyspaceUpdate,
+		Delete:        resourceKeyspaceDelete,
+		Exists:        resourceKeyspaceExists,
+		CustomizeDiff: resourceKeyspaceCustomizeDiff,
+		Importer: &schema.ResourceImporter{
+			State: resourceKeyspaceImport,
+		},
+		SchemaVersion: 1,
+		StateUpgraders: []schema.StateUpgrader{
+			{
+				Version: 0,
+				Type:    resourceCassandraKeyspaceV0().CoreConfigSchema().ImpliedType(),
+				Upgrade: resourceKeyspaceStateUpgradeV0,
+			},
+		},
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:        schema.TypeString,
@@ -40,7 +63,7 @@ func resourceCassandraKeyspace() *schema.Resource {
 				ForceNew:    true,
 				Description: "Name of keyspace",
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
-					name := i.(string)
+					name := unquoteIdentifier(i.(string))
 
 					if !keyspaceRegex.MatchString(name) {
 						errors = append(errors, fmt.Errorf("%s: invalid keyspace name - must match %s", name, keyspaceliteralPattern))
@@ -52,6 +75,7 @@ func resourceCassandraKeyspace() *schema.Resource {
 
 					return
 				},
+				StateFunc: identifierStateFunc,
 			},
 			"replication_strategy": &schema.Schema{
 				Type:        schema.TypeString,
@@ -68,29 +92,50 @@ func resourceCassandraKeyspace() *schema.Resource {
 					return
 				},
 			},
+			"replication": &schema.Schema{
+				Type:          schema.TypeList,
+				Optional:      true,
+				MaxItems:      1,
+				Description:   "Replication of the keyspace, replication_factor for SimpleStrategy or the replication factor of each datacenter for NetworkTopologyStrategy",
+				ConflictsWith: []string{"strategy_options"},
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"replication_factor": &schema.Schema{
+							Type:        schema.TypeInt,
+							Optional:    true,
+							Description: "Number of replicas of each row, only applicable for SimpleStrategy",
+							ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+								replicationFactor := i.(int)
+
+								if replicationFactor <= 0 {
+									errors = append(errors, fmt.Errorf("%d: invalid replication factor - must be greater than 0", replicationFactor))
+								}
+
+								return
+							},
+						},
+						"datacenters": &schema.Schema{
+							Type:        schema.TypeMap,
+							Optional:    true,
+							Description: "Number of replicas of each row in each datacenter, only applicable for NetworkTopologyStrategy",
+							Elem: &schema.Schema{
+								Type: schema.TypeInt,
+							},
+						},
+					},
+				},
+			},
 			"strategy_options": &schema.Schema{
-				Type:        schema.TypeMap,
-				Required:    true,
-				ForceNew:    false,
-				Description: "strategy options used with replication strategy",
+				Type:          schema.TypeMap,
+				Optional:      true,
+				Deprecated:    "use the replication block instead",
+				Description:   "strategy options used with replication strategy",
+				ConflictsWith: []string{"replication"},
 				Elem: &schema.Schema{
 					Type: schema.TypeString,
 				},
-				StateFunc: func(v interface{}) string {
-					strategyOptions := v.(map[string]interface{})
-
-					keys := make([]string, len(strategyOptions))
-
-					for key, value := range strategyOptions {
-
-						strValue := value.(string)
-
-						keys = append(keys, fmt.Sprintf("%q=%q", key, strValue))
-					}
-
-					sort.Strings(keys)
-
-					return hash(strings.Join(keys, ", "))
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeReplicationFactor(old) == normalizeReplicationFactor(new)
 				},
 			},
 			"durable_writes": &schema.Schema{
@@ -100,29 +145,109 @@ func resourceCassandraKeyspace() *schema.Resource {
 				Description: "Enable or disable durable writes - disabling is not recommended",
 				Default:     true,
 			},
+			"validate_datacenters": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Check that the datacenters in the replication block or strategy_options of NetworkTopologyStrategy exist in the cluster - disable while adding a datacenter",
+			},
 		},
 	}
 }
 
-func resourceKeyspaceExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
-	name := d.Get("name").(string)
+func resourceKeyspaceImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	name := unquoteIdentifier(d.Id())
+
+	d.SetId(name)
+	d.Set("name", name)
+	d.Set("validate_datacenters", true)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+// keyspaceName returns the name of the keyspace on the cluster, which is held
+// in the ID once the keyspace is created
+func keyspaceName(d *schema.ResourceData) string {
+	if d.Id() != "" {
+		return d.Id()
+	}
+
+	return getIdentifier(d, "name")
+}
+
+// KeyspaceMetadata represents a keyspace as stored in system_schema.keyspaces
+type KeyspaceMetadata struct {
+	StrategyClass   string
+	StrategyOptions map[string]string
+	DurableWrites   bool
+}
+
+// readKeyspace reads the keyspace from system_schema, gocql's cached metadata
+// is not used as it is only refreshed some time after the schema changes
+func readKeyspace(client *Client, name string) (*KeyspaceMetadata, error) {
+	session, err := client.Session()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var replication map[string]string
+
+	keyspaceMetadata := &KeyspaceMetadata{}
+
+	iter := session.Query(`SELECT durable_writes, replication FROM system_schema.keyspaces WHERE keyspace_name = ?`, name).Iter()
+
+	found := iter.Scan(&keyspaceMetadata.DurableWrites, &replication)
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
 
-	cluster := meta.(*gocql.ClusterConfig)
+	if !found {
+		return nil, gocql.ErrKeyspaceDoesNotExist
+	}
+
+	keyspaceMetadata.StrategyClass = replication["class"]
+	keyspaceMetadata.StrategyOptions = make(map[string]string)
+
+	for key, value := range replication {
+		if key != "class" {
+			keyspaceMetadata.StrategyOptions[key] = value
+		}
+	}
 
-	start := time.Now()
+	return keyspaceMetadata, nil
+}
 
-	session, sessionCreateError := cluster.CreateSession()
+// readKeyspaceMetadata returns the metadata of the keyspace and its name on the
+// cluster. Earlier versions of the provider did not quote names, so Cassandra
+// created a keyspace configured as MyKs as myks. When the quoted name does not
+// exist the lower case one is used, so such a keyspace is not dropped from
+// state and created a second time.
+func readKeyspaceMetadata(client *Client, name string) (*KeyspaceMetadata, string, error) {
+	keyspaceMetadata, err := readKeyspace(client, name)
 
-	elapsed := time.Since(start)
+	if err != gocql.ErrKeyspaceDoesNotExist || strings.ToLower(name) == name {
+		return keyspaceMetadata, name, err
+	}
 
-	log.Printf("Getting a session took %s", elapsed)
+	lowerCaseMetadata, lowerCaseErr := readKeyspace(client, strings.ToLower(name))
 
-	if sessionCreateError != nil {
-		return false, sessionCreateError
+	if lowerCaseErr != nil {
+		return nil, name, err
 	}
 
-	defer session.Close()
-	_, err := session.KeyspaceMetadata(name)
+	log.Printf("[WARN] Keyspace %s does not exist but %s does, it was created without quoting its name and is managed as %s", name, strings.ToLower(name), strings.ToLower(name))
+
+	return lowerCaseMetadata, strings.ToLower(name), nil
+}
+
+func resourceKeyspaceExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	_, _, err := readKeyspaceMetadata(meta.(*Client), keyspaceName(d))
+
+	if err == gocql.ErrKeyspaceDoesNotExist {
+		return false, nil
+	}
 
 	if err != nil {
 		return false, err
@@ -131,150 +256,348 @@ func resourceKeyspaceExists(d *schema.ResourceData, meta interface{}) (b bool, e
 	return true, nil
 }
 
-func generateCreateOrUpdateKeyspaceQueryString(name string, create bool, replicationStrategy string, strategyOptions map[string]interface{}, durableWrites bool) (string, error) {
+// datacenterNodes returns the number of nodes in each datacenter of the
+// cluster, as known by a single host
+func datacenterNodes(session *gocql.Session) (map[string]int, error) {
+	nodes := make(map[string]int)
+
+	err := scanLocalAndPeers(session,
+		`SELECT data_center FROM system.peers`,
+		`SELECT data_center FROM system.local WHERE key = 'local'`,
+		func(iter *gocql.Iter) {
+			var datacenter string
 
-	numberOfStrategyOptions := len(strategyOptions)
+			for iter.Scan(&datacenter) {
+				nodes[datacenter]++
+			}
+		})
 
-	if numberOfStrategyOptions == 0 {
-		return "", fmt.Errorf("Must specify stratgey options - see https://docs.datastax.com/en/cql/3.3/cql/cql_reference/cqlCreateKeyspace.html")
+	if err != nil {
+		return nil, err
 	}
 
-	query := fmt.Sprintf(`%s KEYSPACE %s WITH REPLICATION = { 'class' : '%s'`, boolToAction[create], name, replicationStrategy)
+	return nodes, nil
+}
 
-	for key, value := range strategyOptions {
-		query += fmt.Sprintf(`, '%s' : '%s'`, key, value.(string))
+// normalizeReplicationFactor returns a replication factor the way Cassandra
+// stores it e.g. " 03" becomes "3", other values are returned as they are
+func normalizeReplicationFactor(value string) string {
+	if replicationFactor, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return strconv.Itoa(replicationFactor)
 	}
 
-	query += fmt.Sprintf(` } AND DURABLE_WRITES = %t`, durableWrites)
+	return value
+}
 
-	log.Println("query", query)
+// replicationOptions returns the options of the replication map of the
+// strategy from the replication block
+func replicationOptions(strategy string, replication []interface{}) (map[string]string, error) {
+	if len(replication) == 0 || replication[0] == nil {
+		return nil, fmt.Errorf("replication must be set")
+	}
 
-	return query, nil
-}
+	block := replication[0].(map[string]interface{})
+	replicationFactor := block["replication_factor"].(int)
+	datacenters := block["datacenters"].(map[string]interface{})
 
-func resourceKeyspaceCreate(d *schema.ResourceData, meta interface{}) error {
-	name := d.Get("name").(string)
-	replicationStrategy := d.Get("replication_strategy").(string)
-	strategyOptions := d.Get("strategy_options").(map[string]interface{})
-	durableWrites := d.Get("durable_writes").(bool)
+	options := make(map[string]string)
 
-	query, err := generateCreateOrUpdateKeyspaceQueryString(name, true, replicationStrategy, strategyOptions, durableWrites)
+	switch strategy {
+	case simpleStrategy:
+		if replicationFactor <= 0 || len(datacenters) > 0 {
+			return nil, fmt.Errorf("replication of %s must set replication_factor and not datacenters", simpleStrategy)
+		}
 
-	if err != nil {
-		return err
+		options[replicationFactorOption] = strconv.Itoa(replicationFactor)
+	case networkTopologyStrategy:
+		if len(datacenters) == 0 || replicationFactor > 0 {
+			return nil, fmt.Errorf("replication of %s must set datacenters and not replication_factor", networkTopologyStrategy)
+		}
+
+		for datacenter, value := range datacenters {
+			options[datacenter] = strconv.Itoa(value.(int))
+		}
 	}
 
-	cluster := meta.(*gocql.ClusterConfig)
+	return options, nil
+}
 
-	start := time.Now()
+// flattenReplication returns the replication block of the options of the
+// replication map of the strategy
+func flattenReplication(strategy string, options map[string]string) []interface{} {
+	replicationFactor := 0
+	datacenters := make(map[string]interface{})
 
-	session, sessionCreateError := cluster.CreateSession()
+	for key, value := range options {
+		factor, err := strconv.Atoi(value)
 
-	elapsed := time.Since(start)
+		if err != nil {
+			continue
+		}
 
-	log.Printf("Getting a session took %s", elapsed)
+		if key == replicationFactorOption {
+			replicationFactor = factor
+		} else if strategy == networkTopologyStrategy {
+			datacenters[key] = factor
+		}
+	}
 
-	if sessionCreateError != nil {
-		return sessionCreateError
+	return []interface{}{
+		map[string]interface{}{
+			"replication_factor": replicationFactor,
+			"datacenters":        datacenters,
+		},
 	}
+}
 
-	defer session.Close()
+// keyspaceReplication returns the options of the replication map from the
+// replication block, or from strategy_options when that is set instead
+func keyspaceReplication(d resourceGetter) (map[string]string, error) {
+	strategy := d.Get("replication_strategy").(string)
 
-	d.SetId(name)
+	if replication := d.Get("replication").([]interface{}); len(replication) > 0 {
+		return replicationOptions(strategy, replication)
+	}
+
+	strategyOptions := d.Get("strategy_options").(map[string]interface{})
+
+	if len(strategyOptions) == 0 {
+		return nil, fmt.Errorf("replication must be set - see https://docs.datastax.com/en/cql/3.3/cql/cql_reference/cqlCreateKeyspace.html")
+	}
 
-	return session.Query(query).Exec()
+	options := make(map[string]string)
+
+	for key, value := range strategyOptions {
+		options[key] = normalizeReplicationFactor(value.(string))
+	}
+
+	return options, nil
 }
 
-func resourceKeyspaceRead(d *schema.ResourceData, meta interface{}) error {
-	name := d.Get("name").(string)
+// validateDatacenters returns an error for a datacenter in the replication
+// options which is not in the cluster, as the keyspace would have no replicas in
+// the intended one, and warns when a replication factor exceeds the nodes of its
+// datacenter
+func validateDatacenters(name string, options map[string]string, nodes map[string]int) error {
+	known := make([]string, 0, len(nodes))
 
-	cluster := meta.(*gocql.ClusterConfig)
+	for datacenter := range nodes {
+		known = append(known, datacenter)
+	}
+
+	sort.Strings(known)
 
-	start := time.Now()
+	for datacenter, value := range options {
+		if datacenter == replicationFactorOption {
+			continue
+		}
 
-	session, sessionCreateError := cluster.CreateSession()
+		nodeCount, ok := nodes[datacenter]
 
-	elapsed := time.Since(start)
+		if !ok {
+			return fmt.Errorf("datacenter %s in the replication of keyspace %s does not exist in the cluster, known datacenters are %s - set validate_datacenters = false if it is being added", datacenter, name, strings.Join(known, ", "))
+		}
 
-	log.Printf("Getting a session took %s", elapsed)
+		replicationFactor, err := strconv.Atoi(value)
 
-	if sessionCreateError != nil {
-		return sessionCreateError
+		if err == nil && replicationFactor > nodeCount {
+			log.Printf("[WARN] Replication factor %d of keyspace %s in datacenter %s exceeds its %d nodes", replicationFactor, name, datacenter, nodeCount)
+		}
 	}
 
-	defer session.Close()
+	return nil
+}
+
+// resourceKeyspaceCustomizeDiff validates the replication of a keyspace and,
+// for NetworkTopologyStrategy, its datacenters when they are changed. The
+// datacenters are not checked when the cluster cannot be reached, e.g. before
+// it is created
+func resourceKeyspaceCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	// values interpolated from other resources are only known at apply
+	if !d.NewValueKnown("replication") || !d.NewValueKnown("strategy_options") || !d.NewValueKnown("replication_strategy") {
+		return nil
+	}
 
-	keyspaceMetadata, err := session.KeyspaceMetadata(name)
+	options, err := keyspaceReplication(d)
 
 	if err != nil {
 		return err
 	}
 
-	strategyOptions := make(map[string]string)
+	if !d.Get("validate_datacenters").(bool) || d.Get("replication_strategy").(string) != networkTopologyStrategy {
+		return nil
+	}
 
-	for key, value := range keyspaceMetadata.StrategyOptions {
-		strategyOptions[key] = value.(string)
+	if d.Id() != "" && !d.HasChange("replication") && !d.HasChange("strategy_options") && !d.HasChange("replication_strategy") {
+		return nil
 	}
 
-	strategyClass := strings.TrimPrefix(keyspaceMetadata.StrategyClass, "org.apache.cassandra.locator.")
+	name := unquoteIdentifier(d.Get("name").(string))
 
-	d.Set("replication_strategy", strategyClass)
-	d.Set("durable_writes", keyspaceMetadata.DurableWrites)
-	d.Set("strategy_options", strategyOptions)
-	d.SetId(name)
+	session, err := meta.(*Client).Session()
 
-	return nil
-}
+	if err != nil {
+		log.Printf("[WARN] Unable to validate the datacenters of keyspace %s: %v", name, err)
 
-func resourceKeyspaceDelete(d *schema.ResourceData, meta interface{}) error {
-	name := d.Get("name").(string)
+		return nil
+	}
 
-	cluster := meta.(*gocql.ClusterConfig)
+	nodes, err := datacenterNodes(session)
 
-	start := time.Now()
+	if err != nil {
+		log.Printf("[WARN] Unable to validate the datacenters of keyspace %s: %v", name, err)
 
-	session, sessionCreateError := cluster.CreateSession()
+		return nil
+	}
 
-	elapsed := time.Since(start)
+	return validateDatacenters(name, options, nodes)
+}
 
-	log.Printf("Getting a session took %s", elapsed)
+func generateCreateOrUpdateKeyspaceQueryString(name string, create bool, replicationStrategy string, options map[string]string, durableWrites bool) string {
+	replication := map[string]string{"class": replicationStrategy}
 
-	if sessionCreateError != nil {
-		return sessionCreateError
+	for key, value := range options {
+		replication[key] = value
 	}
 
-	defer session.Close()
+	query := fmt.Sprintf(`%s KEYSPACE %s WITH REPLICATION = %s AND DURABLE_WRITES = %t`, boolToAction[create], cqlIdentifier(name), cqlStringMap(replication), durableWrites)
+
+	log.Println("query", query)
+
+	return query
+}
 
-	return session.Query(fmt.Sprintf(`DROP KEYSPACE %s`, name)).Exec()
+func resourceKeyspaceCreate(d *schema.ResourceData, meta interface{}) error {
+	return resourceKeyspaceCreateOrUpdate(d, meta, true)
 }
 
-func resourceKeyspaceUpdate(d *schema.ResourceData, meta interface{}) error {
-	name := d.Get("name").(string)
+func resourceKeyspaceCreateOrUpdate(d *schema.ResourceData, meta interface{}, create bool) error {
+	name := keyspaceName(d)
 	replicationStrategy := d.Get("replication_strategy").(string)
-	strategyOptions := d.Get("strategy_options").(map[string]interface{})
 	durableWrites := d.Get("durable_writes").(bool)
 
-	query, err := generateCreateOrUpdateKeyspaceQueryString(name, false, replicationStrategy, strategyOptions, durableWrites)
+	options, err := keyspaceReplication(d)
 
 	if err != nil {
 		return err
 	}
 
-	cluster := meta.(*gocql.ClusterConfig)
+	query := generateCreateOrUpdateKeyspaceQueryString(name, create, replicationStrategy, options, durableWrites)
+
+	if create {
+		d.SetId(name)
+	}
+
+	return meta.(*Client).ExecSchemaChange(query)
+}
+
+func resourceKeyspaceRead(d *schema.ResourceData, meta interface{}) error {
+	keyspaceMetadata, name, err := readKeyspaceMetadata(meta.(*Client), keyspaceName(d))
 
-	start := time.Now()
+	if err == gocql.ErrKeyspaceDoesNotExist {
+		log.Printf("[WARN] Keyspace %s no longer exists, removing it from state", name)
+
+		d.SetId("")
+
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	strategyOptions := keyspaceMetadata.StrategyOptions
+
+	strategyClass := strings.TrimPrefix(keyspaceMetadata.StrategyClass, "org.apache.cassandra.locator.")
 
-	session, sessionCreateError := cluster.CreateSession()
+	d.Set("replication_strategy", strategyClass)
+	d.Set("durable_writes", keyspaceMetadata.DurableWrites)
+
+	// only the attribute in use is set, the deprecated strategy_options is
+	// kept for keyspaces which were created with it
+	if len(d.Get("strategy_options").(map[string]interface{})) > 0 {
+		d.Set("strategy_options", strategyOptions)
+	} else {
+		d.Set("replication", flattenReplication(strategyClass, strategyOptions))
+	}
+
+	d.SetId(name)
+
+	return nil
+}
+
+func resourceKeyspaceDelete(d *schema.ResourceData, meta interface{}) error {
+	name := keyspaceName(d)
+
+	return meta.(*Client).ExecSchemaChange(fmt.Sprintf(`DROP KEYSPACE %s`, cqlIdentifier(name)))
+}
+
+func resourceKeyspaceUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceKeyspaceCreateOrUpdate(d, meta, false)
+}
+
+// resourceCassandraKeyspaceV0 is the schema of version 0, which stored
+// strategy_options through a StateFunc hashing the options
+func resourceCassandraKeyspaceV0() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			"name": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"replication_strategy": &schema.Schema{
+				Type:     schema.TypeString,
+				Required: true,
+			},
+			"strategy_options": &schema.Schema{
+				Type:     schema.TypeMap,
+				Required: true,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"durable_writes": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+			"validate_datacenters": &schema.Schema{
+				Type:     schema.TypeBool,
+				Optional: true,
+			},
+		},
+	}
+}
+
+// resourceKeyspaceStateUpgradeV0 normalizes the replication factors in the
+// strategy_options of version 0, e.g. " 3" to "3", as they are now compared as
+// numbers. The StateFunc version 0 declared on the map was never applied, so
+// the options are held as they were configured. Anything other than a map of
+// strings is dropped, the next refresh reads the replication of the keyspace
+// from the cluster
+func resourceKeyspaceStateUpgradeV0(rawState map[string]interface{}, meta interface{}) (map[string]interface{}, error) {
+	strategyOptions, ok := rawState["strategy_options"].(map[string]interface{})
+
+	if !ok {
+		delete(rawState, "strategy_options")
+
+		return rawState, nil
+	}
+
+	options := make(map[string]interface{})
+
+	for key, value := range strategyOptions {
+		option, ok := value.(string)
 
-	elapsed := time.Since(start)
+		if !ok {
+			delete(rawState, "strategy_options")
 
-	log.Printf("Getting a session took %s", elapsed)
+			return rawState, nil
+		}
 
-	if sessionCreateError != nil {
-		return sessionCreateError
+		options[key] = normalizeReplicationFactor(option)
 	}
 
-	defer session.Close()
+	rawState["strategy_options"] = options
 
-	return session.Query(query).Exec()
+	return rawState, nil
 }
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+// keyspaceStateV0 is the state of a keyspace as written by version 0 of the
+// resource, strategy_options holds the options as they were configured
+const keyspaceStateV0 = `{
+	"id": "events",
+	"name": "events",
+	"replication_strategy": "NetworkTopologyStrategy",
+	"strategy_options": {
+		"dc1": " 3",
+		"dc2": "02"
+	},
+	"durable_writes": true
+}`
+
+func TestResourceKeyspaceStateUpgradeV0(t *testing.T) {
+	var rawState map[string]interface{}
+
+	if err := json.Unmarshal([]byte(keyspaceStateV0), &rawState); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := schema.JSONMapToStateValue(rawState, resourceCassandraKeyspaceV0().CoreConfigSchema()); err != nil {
+		t.Fatalf("state does not match version 0: %v", err)
+	}
+
+	upgraded, err := resourceKeyspaceStateUpgradeV0(rawState, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string]interface{}{
+		"dc1": "3",
+		"dc2": "2",
+	}
+
+	if !reflect.DeepEqual(upgraded["strategy_options"], expected) {
+		t.Errorf("expected strategy_options %v, got %v", expected, upgraded["strategy_options"])
+	}
+
+	if upgraded["name"] != "events" || upgraded["replication_strategy"] != "NetworkTopologyStrategy" || upgraded["durable_writes"] != true {
+		t.Errorf("unexpected change to the other attributes: %v", upgraded)
+	}
+
+	if _, err := schema.JSONMapToStateValue(upgraded, resourceCassandraKeyspace().CoreConfigSchema()); err != nil {
+		t.Errorf("upgraded state does not match version 1: %v", err)
+	}
+}
+
+func TestResourceKeyspaceStateUpgradeV0WithoutOptions(t *testing.T) {
+	rawState := map[string]interface{}{
+		"id":                   "events",
+		"name":                 "events",
+		"replication_strategy": "SimpleStrategy",
+		"strategy_options":     nil,
+		"durable_writes":       true,
+	}
+
+	upgraded, err := resourceKeyspaceStateUpgradeV0(rawState, nil)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := upgraded["strategy_options"]; ok {
+		t.Errorf("expected strategy_options to be dropped, got %v", upgraded["strategy_options"])
+	}
+
+	if _, err := schema.JSONMapToStateValue(upgraded, resourceCassandraKeyspace().CoreConfigSchema()); err != nil {
+		t.Errorf("upgraded state does not match version 1: %v", err)
+	}
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+var (
+	viewIntOptions = []string{"gc_grace_seconds"}
+
+	// the setting was renamed in Cassandra 4.1
+	materializedViewSettings = []string{"enable_materialized_views", "materialized_views_enabled"}
+)
+
+func resourceCassandraMaterializedView() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceMaterializedViewCreate,
+		Read:          resourceMaterializedViewRead,
+		Update:        resourceMaterializedViewUpdate,
+		Delete:        resourceMaterializedViewDelete,
+		Exists:        resourceMaterializedViewExists,
+		CustomizeDiff: resourceMaterializedViewCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"keyspace": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the keyspace the view and its base table belong to",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "keyspace", keyspaceRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the materialized view",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "view name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"base_table": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the table the view selects from",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "table name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"columns": &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				ForceNew:    true,
+				Description: "Columns of the base table selected into the view, all columns are selected when empty",
+				Set:         schema.HashString,
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"where_clause": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "WHERE clause of the view, every primary key column must be restricted with IS NOT NULL",
+				DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+					return normalizeWhereClause(old) == normalizeWhereClause(new)
+				},
+			},
+			"partition_keys": &schema.Schema{
+				Type:        schema.TypeList,
+				Required:    true,
+				ForceNew:    true,
+				MinItems:    1,
+				Description: "Ordered list of column names making up the partition key of the view",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"clustering_key":   clusteringKeySchema("Ordered list of clustering columns of the view"),
+			"compaction":       tableMapOptionSchema("Compaction options, must include class when set"),
+			"compression":      tableMapOptionSchema("Compression options"),
+			"caching":          tableMapOptionSchema("Caching options e.g. keys and rows_per_partition"),
+			"gc_grace_seconds": gcGraceSecondsSchema(),
+			"validate_enabled": &schema.Schema{
+				Type:        schema.TypeBool,
+				Optional:    true,
+				Default:     true,
+				Description: "Check that materialized views are enabled on the cluster before the view is created - disable to plan a view before turning them on",
+			},
+		},
+	}
+}
+
+// MaterializedView represents a Cassandra materialized view as stored in
+// system_schema.views and system_schema.columns
+type MaterializedView struct {
+	BaseTable         string
+	IncludeAllColumns bool
+	WhereClause       string
+	Columns           []string
+	PartitionKeys     []string
+	ClusteringKeys    []ClusteringKey
+	Compaction        map[string]string
+	Compression       map[string]string
+	Caching           map[string]string
+	GcGraceSeconds    int
+}
+
+func normalizeWhereClause(whereClause string) string {
+	return strings.ToLower(strings.Join(strings.Fields(whereClause), " "))
+}
+
+func readMaterializedView(session *gocql.Session, keyspace string, name string) (*MaterializedView, bool, error) {
+	view := &MaterializedView{}
+
+	iter := session.Query(`SELECT base_table_name, include_all_columns, where_clause, compaction, compression, caching, gc_grace_seconds FROM system_schema.views WHERE keyspace_name = ? AND view_name = ?`, keyspace, name).Iter()
+
+	found := iter.Scan(&view.BaseTable, &view.IncludeAllColumns, &view.WhereClause, &view.Compaction, &view.Compression, &view.Caching, &view.GcGraceSeconds)
+
+	if err := iter.Close(); err != nil {
+		return nil, false, err
+	}
+
+	if !found {
+		return view, false, nil
+	}
+
+	columns, partitionKeys, clusteringKeys, err := readTableColumns(session, keyspace, name)
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	for _, column := range columns {
+		view.Columns = append(view.Columns, column.Name)
+	}
+
+	sort.Strings(view.Columns)
+
+	view.PartitionKeys = partitionKeys
+	view.ClusteringKeys = clusteringKeys
+
+	return view, true, nil
+}
+
+// materializedViewsDisabled reports whether the cluster has materialized views
+// turned off, clusters before 4.0 have no settings table to check
+func materializedViewsDisabled(session *gocql.Session) (bool, error) {
+	for _, setting := range materializedViewSettings {
+		var value string
+
+		iter := session.Query(`SELECT value FROM system_views.settings WHERE name = ?`, setting).Iter()
+
+		found := iter.Scan(&value)
+
+		if err := iter.Close(); err != nil {
+			return false, err
+		}
+
+		if found {
+			return value == "false", nil
+		}
+	}
+
+	return false, nil
+}
+
+// resourceMaterializedViewCustomizeDiff fails the plan of a view which is about
+// to be created on a cluster which has them disabled, unless validate_enabled
+// is turned off as the setting will be turned on before the apply
+func resourceMaterializedViewCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() != "" || !d.Get("validate_enabled").(bool) {
+		return nil
+	}
+
+	session, err := meta.(*Client).Session()
+
+	if err != nil {
+		log.Printf("[WARN] Unable to check whether materialized views are enabled: %v", err)
+
+		return nil
+	}
+
+	disabled, err := materializedViewsDisabled(session)
+
+	if err != nil {
+		log.Printf("[DEBUG] Unable to read materialized view settings: %v", err)
+
+		return nil
+	}
+
+	if disabled {
+		return fmt.Errorf("materialized views are disabled on the cluster, creating %s.%s will fail until enable_materialized_views is turned on in cassandra.yaml - set validate_enabled = false if it is being turned on", d.Get("keyspace").(string), d.Get("name").(string))
+	}
+
+	return nil
+}
+
+func generateCreateMaterializedViewQueryString(d *schema.ResourceData) string {
+	var buffer bytes.Buffer
+
+	keyspace := getIdentifier(d, "keyspace")
+	partitionKeys := tablePartitionKeys(d.Get("partition_keys").([]interface{}))
+	clusteringKeys := tableClusteringKeys(d.Get("clustering_key").([]interface{}))
+
+	selection := "*"
+
+	if columns := d.Get("columns").(*schema.Set); columns.Len() > 0 {
+		names := make([]string, 0, columns.Len())
+
+		for _, column := range columns.List() {
+			names = append(names, column.(string))
+		}
+
+		sort.Strings(names)
+
+		selection = cqlIdentifiers(names)
+	}
+
+	// the WHERE clause is CQL written in the configuration and is used as is
+	buffer.WriteString(fmt.Sprintf(`CREATE MATERIALIZED VIEW %s AS SELECT %s FROM %s WHERE %s`, cqlQualifiedName(keyspace, getIdentifier(d, "name")), selection, cqlQualifiedName(keyspace, getIdentifier(d, "base_table")), d.Get("where_clause").(string)))
+
+	primaryKey, clusteringOrder := generatePrimaryKey(partitionKeys, clusteringKeys)
+
+	buffer.WriteString(" " + primaryKey)
+
+	options := tableOptions(d, viewIntOptions, false)
+
+	if clusteringOrder != "" {
+		options = append([]string{clusteringOrder}, options...)
+	}
+
+	if len(options) > 0 {
+		buffer.WriteString(fmt.Sprintf(` WITH %s`, strings.Join(options, " AND ")))
+	}
+
+	query := buffer.String()
+
+	log.Println("query", query)
+
+	return query
+}
+
+func resourceMaterializedViewExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return false, sessionCreateError
+	}
+
+	_, found, err := readMaterializedView(session, keyspace, name)
+
+	return found, err
+}
+
+func resourceMaterializedViewCreate(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	err := meta.(*Client).ExecSchemaChange(generateCreateMaterializedViewQueryString(d))
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s.%s", keyspace, name))
+
+	return resourceMaterializedViewRead(d, meta)
+}
+
+func resourceMaterializedViewRead(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return sessionCreateError
+	}
+
+	view, found, err := readMaterializedView(session, keyspace, name)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("materialized view %s does not exist in keyspace %s", name, keyspace)
+	}
+
+	// primary key columns are always part of the view, so they are only tracked
+	// in columns when they were selected explicitly
+	columns := make([]interface{}, 0, len(view.Columns))
+
+	if !view.IncludeAllColumns {
+		configured := d.Get("columns").(*schema.Set)
+
+		keyColumns := make(map[string]bool)
+
+		for _, key := range view.PartitionKeys {
+			keyColumns[key] = true
+		}
+
+		for _, key := range view.ClusteringKeys {
+			keyColumns[key.Name] = true
+		}
+
+		for _, column := range view.Columns {
+			if keyColumns[column] && !configured.Contains(column) {
+				continue
+			}
+
+			columns = append(columns, column)
+		}
+	}
+
+	clusteringKeys := make([]map[string]interface{}, 0, len(view.ClusteringKeys))
+
+	for _, key := range view.ClusteringKeys {
+		clusteringKeys = append(clusteringKeys, map[string]interface{}{
+			"name":  key.Name,
+			"order": key.Order,
+		})
+	}
+
+	d.Set("base_table", view.BaseTable)
+	d.Set("columns", schema.NewSet(schema.HashString, columns))
+	d.Set("where_clause", view.WhereClause)
+	d.Set("partition_keys", view.PartitionKeys)
+	d.Set("clustering_key", clusteringKeys)
+	d.Set("compaction", normalizeTableOption(d.Get("compaction").(map[string]interface{}), view.Compaction))
+	d.Set("compression", normalizeTableOption(d.Get("compression").(map[string]interface{}), view.Compression))
+	d.Set("caching", normalizeTableOption(d.Get("caching").(map[string]interface{}), view.Caching))
+	d.Set("gc_grace_seconds", view.GcGraceSeconds)
+
+	return nil
+}
+
+func resourceMaterializedViewUpdate(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	options := tableOptions(d, viewIntOptions, true)
+
+	if len(options) > 0 {
+		query := fmt.Sprintf(`ALTER MATERIALIZED VIEW %s WITH %s`, cqlQualifiedName(keyspace, name), strings.Join(options, " AND "))
+
+		log.Println("query", query)
+
+		if err := meta.(*Client).ExecSchemaChange(query); err != nil {
+			return err
+		}
+	}
+
+	return resourceMaterializedViewRead(d, meta)
+}
+
+func resourceMaterializedViewDelete(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	return meta.(*Client).ExecSchemaChange(fmt.Sprintf(`DROP MATERIALIZED VIEW %s`, cqlQualifiedName(keyspace, name)))
+}
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"regexp"
-	"time"
 
 	"github.com/gocql/gocql"
 	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
@@ -14,11 +13,14 @@ import (
 const (
 	validPasswordRegexLiteral = `^[^"]{40,512}$`
 	validRoleRegexLiteral     = `^[^"]{1,256}$`
+	// bcrypt hash as stored by Cassandra in system_auth.roles
+	validHashedPasswordRegexLiteral = `^\$2[abxy]?\$[0-9]{2}\$[./A-Za-z0-9]{53}$`
 )
 
 var (
-	validPasswordRegex, _ = regexp.Compile(validPasswordRegexLiteral)
-	validRoleRegex, _     = regexp.Compile(validRoleRegexLiteral)
+	validPasswordRegex, _       = regexp.Compile(validPasswordRegexLiteral)
+	validRoleRegex, _           = regexp.Compile(validRoleRegexLiteral)
+	validHashedPasswordRegex, _ = regexp.Compile(validHashedPasswordRegexLiteral)
 )
 
 func resourceCassandraRole() *schema.Resource {
@@ -28,6 +30,9 @@ func resourceCassandraRole() *schema.Resource {
 		Update: resourceRoleUpdate,
 		Delete: resourceRoleDelete,
 		Exists: resourceRoleExists,
+		Importer: &schema.ResourceImporter{
+			State: resourceRoleImport,
+		},
 		Schema: map[string]*schema.Schema{
 			"name": &schema.Schema{
 				Type:        schema.TypeString,
@@ -35,14 +40,15 @@ func resourceCassandraRole() *schema.Resource {
 				ForceNew:    true,
 				Description: "Name of role - must contain between 1 and 256 characters",
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
-					name := i.(string)
+					name := unquoteIdentifier(i.(string))
 
 					if !validRoleRegex.MatchString(name) {
-						errors = append(errors, fmt.Errorf("name must contain between 1 and 256 chars and must not contain single quote character"))
+						errors = append(errors, fmt.Errorf("name must contain between 1 and 256 chars and must not contain double quote character"))
 					}
 
 					return
 				},
+				StateFunc: identifierStateFunc,
 			},
 			"super_user": &schema.Schema{
 				Type:        schema.TypeBool,
@@ -60,9 +66,9 @@ func resourceCassandraRole() *schema.Resource {
 			},
 			"password": &schema.Schema{
 				Type:        schema.TypeString,
-				Required:    true,
+				Optional:    true,
 				ForceNew:    false,
-				Description: "Password for user when using Cassandra internal authentication",
+				Description: "Password for user when using Cassandra internal authentication - leave unset to not manage the password",
 				Sensitive:   true,
 				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
 					password := i.(string)
@@ -73,12 +79,31 @@ func resourceCassandraRole() *schema.Resource {
 
 					return
 				},
+				ConflictsWith: []string{"hashed_password"},
+			},
+			"hashed_password": &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				ForceNew:    false,
+				Description: "bcrypt hash of the password for user when using Cassandra internal authentication, requires Cassandra 4.1 or later - leave unset to not manage the password",
+				Sensitive:   true,
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					hashedPassword := i.(string)
+
+					if !validHashedPasswordRegex.MatchString(hashedPassword) {
+						errors = append(errors, fmt.Errorf("hashed_password must be a bcrypt hash e.g. $2a$10$ followed by 53 chars"))
+					}
+
+					return
+				},
+				ConflictsWith: []string{"password"},
 			},
+			identifierConsistency: authConsistencySchema(),
 		},
 	}
 }
 
-func readRole(session *gocql.Session, name string) (string, bool, bool, string, error) {
+func readRole(session *gocql.Session, name string, consistency gocql.Consistency) (string, bool, bool, string, error) {
 
 	var (
 		role        string
@@ -87,39 +112,40 @@ func readRole(session *gocql.Session, name string) (string, bool, bool, string,
 		saltedHash  string
 	)
 
-	iter := session.Query(`select role, can_login, is_superuser, salted_hash from system_auth.roles where role = ?`, name).Iter()
-
-	defer iter.Close()
+	iter := session.Query(`select role, can_login, is_superuser, salted_hash from system_auth.roles where role = ?`, name).Consistency(consistency).Iter()
 
 	log.Printf("read role query returned %d", iter.NumRows())
 
-	for iter.Scan(&role, &canLogin, &isSuperUser, &saltedHash) {
-		return role, canLogin, isSuperUser, saltedHash, nil
+	iter.Scan(&role, &canLogin, &isSuperUser, &saltedHash)
+
+	// a missing role returns no rows and an empty name, while connectivity and
+	// auth errors are only reported when the iterator is closed
+	if err := iter.Close(); err != nil {
+		return "", false, false, "", err
 	}
 
-	return "", false, false, "", nil
+	return role, canLogin, isSuperUser, saltedHash, nil
 }
 
-func resourceRoleExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
-	name := d.Get("name").(string)
-
-	cluster := meta.(*gocql.ClusterConfig)
+func resourceRoleImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	name := unquoteIdentifier(d.Id())
 
-	start := time.Now()
+	d.SetId(name)
+	d.Set("name", name)
 
-	session, sessionCreateError := cluster.CreateSession()
+	return []*schema.ResourceData{d}, nil
+}
 
-	elapsed := time.Since(start)
+func resourceRoleExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	name := getIdentifier(d, "name")
 
-	log.Printf("Getting a session took %s", elapsed)
+	session, sessionCreateError := meta.(*Client).Session()
 
 	if sessionCreateError != nil {
 		return false, sessionCreateError
 	}
 
-	defer session.Close()
-
-	_name, _, _, _, err := readRole(session, name)
+	_name, _, _, _, err := readRole(session, name, authConsistency(d))
 
 	condition := _name == name && err == nil
 
@@ -133,28 +159,23 @@ func resourceRoleCreate(d *schema.ResourceData, meta interface{}) error {
 }
 
 func resourceRoleCreateOrUpdate(d *schema.ResourceData, meta interface{}, createRole bool) error {
-	name := d.Get("name").(string)
+	name := getIdentifier(d, "name")
 	superUser := d.Get("super_user").(bool)
 	login := d.Get("login").(bool)
 	password := d.Get("password").(string)
+	hashedPassword := d.Get("hashed_password").(string)
 
-	cluster := meta.(*gocql.ClusterConfig)
-
-	start := time.Now()
-
-	session, sessionCreateError := cluster.CreateSession()
+	query := fmt.Sprintf(`%s ROLE %s WITH LOGIN = %v AND SUPERUSER = %v`, boolToAction[createRole], cqlString(name), login, superUser)
 
-	elapsed := time.Since(start)
-
-	log.Printf("Getting a session took %s", elapsed)
-
-	if sessionCreateError != nil {
-		return sessionCreateError
+	if password != "" {
+		query += fmt.Sprintf(` AND PASSWORD = %s`, cqlString(password))
 	}
 
-	defer session.Close()
+	if hashedPassword != "" {
+		query += fmt.Sprintf(` AND HASHED PASSWORD = %s`, cqlString(hashedPassword))
+	}
 
-	createErr := session.Query(fmt.Sprintf(`%s ROLE '%s' WITH PASSWORD = '%s' AND LOGIN = %v AND SUPERUSER = %v`, boolToAction[createRole], name, password, login, superUser)).Exec()
+	createErr := meta.(*Client).ExecStatement(query)
 	if createErr != nil {
 		return createErr
 	}
@@ -164,42 +185,55 @@ func resourceRoleCreateOrUpdate(d *schema.ResourceData, meta interface{}, create
 	d.Set("super_user", superUser)
 	d.Set("login", login)
 	d.Set("password", password)
+	d.Set("hashed_password", hashedPassword)
 
 	return nil
 }
 
 func resourceRoleRead(d *schema.ResourceData, meta interface{}) error {
-	name := d.Get("name").(string)
+	name := getIdentifier(d, "name")
 	password := d.Get("password").(string)
+	hashedPassword := d.Get("hashed_password").(string)
 
-	cluster := meta.(*gocql.ClusterConfig)
-
-	start := time.Now()
-
-	session, sessionCreateError := cluster.CreateSession()
-
-	elapsed := time.Since(start)
-
-	log.Printf("Getting a session took %s", elapsed)
+	session, sessionCreateError := meta.(*Client).Session()
 
 	if sessionCreateError != nil {
 		return sessionCreateError
 	}
-
-	defer session.Close()
-	_name, login, superUser, saltedHash, readRoleErr := readRole(session, name)
+	_name, login, superUser, saltedHash, readRoleErr := readRole(session, name, authConsistency(d))
 
 	if readRoleErr != nil {
 		return readRoleErr
 	}
 
-	result := bcrypt.CompareHashAndPassword([]byte(saltedHash), []byte(password))
+	if _name == "" {
+		log.Printf("[WARN] Role %s no longer exists, removing it from state", name)
+
+		d.SetId("")
+
+		return nil
+	}
 
 	d.SetId(_name)
 	d.Set("name", _name)
 	d.Set("super_user", superUser)
 	d.Set("login", login)
 
+	if hashedPassword != "" {
+		// the hash is stored as given, a different hash means the password
+		// has changed between runs
+		d.Set("hashed_password", saltedHash)
+
+		return nil
+	}
+
+	if password == "" {
+		// password is not managed, e.g. the role was imported
+		return nil
+	}
+
+	result := bcrypt.CompareHashAndPassword([]byte(saltedHash), []byte(password))
+
 	if result == nil {
 		d.Set("password", password)
 	} else {
@@ -211,25 +245,9 @@ func resourceRoleRead(d *schema.ResourceData, meta interface{}) error {
 }
 
 func resourceRoleDelete(d *schema.ResourceData, meta interface{}) error {
-	name := d.Get("name").(string)
-
-	cluster := meta.(*gocql.ClusterConfig)
-
-	start := time.Now()
-
-	session, sessionCreateError := cluster.CreateSession()
-
-	elapsed := time.Since(start)
-
-	log.Printf("Getting a session took %s", elapsed)
-
-	if sessionCreateError != nil {
-		return sessionCreateError
-	}
-
-	defer session.Close()
+	name := getIdentifier(d, "name")
 
-	return session.Query(fmt.Sprintf(`DROP ROLE '%s'`, name)).Exec()
+	return meta.(*Client).ExecStatement(fmt.Sprintf(`DROP ROLE %s`, cqlString(name)))
 }
 
 func resourceRoleUpdate(d *schema.ResourceData, meta interface{}) error {
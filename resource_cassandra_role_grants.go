@@ -0,0 +1,681 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const (
+	identifierPermission = "permission"
+	identifierIdentifier = "identifier"
+
+	cassandraMarshalPrefix = "org.apache.cassandra.db.marshal."
+)
+
+var (
+	// CQL types of function arguments, keyed by the marshal class Cassandra
+	// uses in the names of function resources
+	marshalTypeToCQLType = map[string]string{
+		"AsciiType":         "ascii",
+		"LongType":          "bigint",
+		"BytesType":         "blob",
+		"BooleanType":       "boolean",
+		"CounterColumnType": "counter",
+		"DecimalType":       "decimal",
+		"DoubleType":        "double",
+		"DurationType":      "duration",
+		"FloatType":         "float",
+		"InetAddressType":   "inet",
+		"Int32Type":         "int",
+		"ShortType":         "smallint",
+		"SimpleDateType":    "date",
+		"TimeType":          "time",
+		"TimestampType":     "timestamp",
+		"TimeUUIDType":      "timeuuid",
+		"ByteType":          "tinyint",
+		"UTF8Type":          "text",
+		"UUIDType":          "uuid",
+		"IntegerType":       "varint",
+	}
+
+	// names of user defined types which can be written without quotes
+	unquotedTypeNameRegex = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+	// privileges Cassandra grants for all on each resource type, i.e. the
+	// permissions applicable to the resource in Cassandra, in the order of
+	// allPrivileges
+	resourceTypeToAllPrivileges = map[string][]string{
+		resourceAllKeyspaces:           {privilegeSelect, privilegeCreate, privilegeAlter, privilegeDrop, privilegeModify, privilegeAuthorize},
+		resourceKeyspace:               {privilegeSelect, privilegeCreate, privilegeAlter, privilegeDrop, privilegeModify, privilegeAuthorize},
+		resourceTable:                  {privilegeSelect, privilegeAlter, privilegeDrop, privilegeModify, privilegeAuthorize},
+		resourceAllFunctions:           {privilegeCreate, privilegeAlter, privilegeDrop, privilegeAuthorize, privilegeExecute},
+		resourceAllFunctionsInKeyspace: {privilegeCreate, privilegeAlter, privilegeDrop, privilegeAuthorize, privilegeExecute},
+		resourceFunction:               {privilegeAlter, privilegeDrop, privilegeAuthorize, privilegeExecute},
+		resourceAllRoles:               {privilegeCreate, privilegeAlter, privilegeDrop, privilegeAuthorize, privilegeDescribe},
+		resourceRole:                   {privilegeAlter, privilegeDrop, privilegeAuthorize},
+	}
+)
+
+func resourceCassandraRoleGrants() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRoleGrantsCreate,
+		Read:   resourceRoleGrantsRead,
+		Update: resourceRoleGrantsUpdate,
+		Delete: resourceRoleGrantsDelete,
+		Exists: resourceRoleGrantsExists,
+		Importer: &schema.ResourceImporter{
+			State: resourceRoleGrantsImport,
+		},
+		Schema: map[string]*schema.Schema{
+			identifierConsistency: authConsistencySchema(),
+			identifierGrantee: &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "role name whose permissions are managed, any permission not declared is revoked",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "grantee", validRoleRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			identifierPermission: &schema.Schema{
+				Type:        schema.TypeSet,
+				Optional:    true,
+				Description: "Privileges granted to the grantee on a resource",
+				Elem:        rolePermissionResource(),
+				Set:         hashRolePermission,
+			},
+		},
+	}
+}
+
+func rolePermissionResource() *schema.Resource {
+	return &schema.Resource{
+		Schema: map[string]*schema.Schema{
+			identifierResourceType: &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				Description: fmt.Sprintf("Resource type the privileges are granted on. Must be one of %s", strings.Join(allResources, ", ")),
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					resourceType := i.(string)
+
+					if !validResources[resourceType] {
+						errors = append(errors, fmt.Errorf("%s in not a valid resourceType, must be one of %s", resourceType, strings.Join(allResources, ", ")))
+					}
+
+					return
+				},
+			},
+			identifierKeyspaceName: &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: fmt.Sprintf("keyspace qualifier to the resource, only applicable for resource %s", strings.Join(resourcesThatRequireKeyspaceQualifier, ", ")),
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "keyspace", keyspaceRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			identifierIdentifier: &schema.Schema{
+				Type:        schema.TypeString,
+				Optional:    true,
+				Description: "function signature, table name, role name, mbean name or mbean pattern of the resource",
+				StateFunc:   identifierStateFunc,
+			},
+			identifierPrivileges: &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				MinItems:    1,
+				Description: fmt.Sprintf("Privileges granted on the resource, each one of %s, %s", privilegeAll, strings.Join(allPrivileges, ", ")),
+				Set:         schema.HashString,
+				Elem: &schema.Schema{
+					Type:         schema.TypeString,
+					ValidateFunc: validPrivilege,
+				},
+			},
+		},
+	}
+}
+
+// hashRolePermission hashes a permission block with its keyspace and
+// identifier unquoted, so a quoted name matches the name read back from the
+// cluster
+func hashRolePermission(v interface{}) int {
+	block := make(map[string]interface{})
+
+	for key, value := range v.(map[string]interface{}) {
+		block[key] = value
+	}
+
+	for _, key := range []string{identifierKeyspaceName, identifierIdentifier} {
+		if name, ok := block[key].(string); ok {
+			block[key] = unquoteIdentifier(name)
+		}
+	}
+
+	return schema.HashResource(rolePermissionResource())(block)
+}
+
+// RolePermission represents the privileges of a role on a single resource
+type RolePermission struct {
+	ResourceType string
+	Keyspace     string
+	Identifier   string
+	Privileges   []string
+}
+
+// Key identifies the resource of the permission the same way however it was
+// written, mbean patterns are stored as mbeans and function argument types
+// are normalized
+func (permission *RolePermission) Key() string {
+	resourceType := permission.ResourceType
+	identifier := permission.Identifier
+
+	if resourceType == resourceMbeans {
+		resourceType = resourceMbean
+	}
+
+	if resourceType == resourceFunction {
+		// the argument types are normalized as a whole, as a quoted user
+		// defined type may contain commas
+		if index := strings.Index(identifier, "("); index > 0 {
+			identifier = fmt.Sprintf("%s(%s)", identifier[:index], normalizeColumnType(strings.TrimSuffix(identifier[index+1:], ")")))
+		}
+	}
+
+	return strings.Join([]string{resourceType, permission.Keyspace, identifier}, grantImportIDSeparator)
+}
+
+// Grants returns a grant per privilege of the permission, with all expanded
+// to the privileges applicable to the resource type as Cassandra stores them
+func (permission *RolePermission) Grants(grantee string) []*Grant {
+	privileges := permission.Privileges
+
+	for _, privilege := range privileges {
+		if privilege == privilegeAll {
+			privileges = applicablePrivileges(permission.ResourceType)
+			break
+		}
+	}
+
+	grants := make([]*Grant, 0, len(privileges))
+
+	for _, privilege := range privileges {
+		grants = append(grants, &Grant{privilege, permission.ResourceType, grantee, permission.Keyspace, permission.Identifier})
+	}
+
+	return grants
+}
+
+// applicablePrivileges returns the privileges all expands to on the resource
+// type, Cassandra grants every permission applicable to the resource
+func applicablePrivileges(resourceType string) []string {
+	return append([]string(nil), resourceTypeToAllPrivileges[resourceType]...)
+}
+
+func rolePermissionsFromSet(set *schema.Set) []*RolePermission {
+	permissions := make([]*RolePermission, 0, set.Len())
+
+	for _, raw := range set.List() {
+		block := raw.(map[string]interface{})
+
+		permission := &RolePermission{
+			ResourceType: block[identifierResourceType].(string),
+			Keyspace:     unquoteIdentifier(block[identifierKeyspaceName].(string)),
+			Identifier:   unquoteIdentifier(block[identifierIdentifier].(string)),
+		}
+
+		for _, privilege := range block[identifierPrivileges].(*schema.Set).List() {
+			permission.Privileges = append(permission.Privileges, privilege.(string))
+		}
+
+		sort.Strings(permission.Privileges)
+
+		permissions = append(permissions, permission)
+	}
+
+	return permissions
+}
+
+// parsePermissions returns the configured permissions once each one is
+// validated against the privileges applicable to its resource type
+func parsePermissions(d *schema.ResourceData) ([]*RolePermission, error) {
+	permissions := rolePermissionsFromSet(d.Get(identifierPermission).(*schema.Set))
+
+	for _, permission := range permissions {
+		if err := validateRolePermission(permission); err != nil {
+			return nil, err
+		}
+	}
+
+	return permissions, nil
+}
+
+func validateRolePermission(permission *RolePermission) error {
+	requiresKeyspaceQualifier := false
+
+	for _, resourceType := range resourcesThatRequireKeyspaceQualifier {
+		if permission.ResourceType == resourceType {
+			requiresKeyspaceQualifier = true
+		}
+	}
+
+	if requiresKeyspaceQualifier && permission.Keyspace == "" {
+		return fmt.Errorf("keyspace name must be set for resourceType %s", permission.ResourceType)
+	}
+
+	if !requiresKeyspaceQualifier && permission.Keyspace != "" {
+		return fmt.Errorf("keyspace name is not applicable for resourceType %s", permission.ResourceType)
+	}
+
+	if _, ok := resourceTypeToIdentifier[permission.ResourceType]; ok != (permission.Identifier != "") {
+		if ok {
+			return fmt.Errorf("identifier must be set for resourceType %s", permission.ResourceType)
+		}
+
+		return fmt.Errorf("identifier is not applicable for resourceType %s", permission.ResourceType)
+	}
+
+	if permission.ResourceType == resourceFunction && !validFunctionNameRegex.MatchString(permission.Identifier) {
+		return fmt.Errorf("%s in not a valid function name", permission.Identifier)
+	}
+
+	if permission.ResourceType == resourceTable && !validTableNameRegex.MatchString(permission.Identifier) {
+		return fmt.Errorf("%s in not a valid table name", permission.Identifier)
+	}
+
+	if permission.ResourceType == resourceRole && !validRoleRegex.MatchString(permission.Identifier) {
+		return fmt.Errorf("%s in not a valid role name", permission.Identifier)
+	}
+
+	for _, privilege := range permission.Privileges {
+		allowedResourceTypes := privilegeToResourceTypesMap[privilege]
+
+		matchFound := false
+
+		for _, resourceType := range allowedResourceTypes {
+			if resourceType == permission.ResourceType {
+				matchFound = true
+			}
+		}
+
+		if !matchFound {
+			return fmt.Errorf("%s resource not applicable for privilege %s - valid resourceTypes are %s", permission.ResourceType, privilege, strings.Join(allowedResourceTypes, ", "))
+		}
+	}
+
+	return nil
+}
+
+// parseFunctionResourceName converts the name Cassandra stores a function
+// resource under, e.g. double_it[org.apache.cassandra.db.marshal.Int32Type],
+// into its signature double_it(int)
+func parseFunctionResourceName(name string) string {
+	index := strings.Index(name, "[")
+
+	if index < 0 {
+		return name
+	}
+
+	var argumentTypes []string
+
+	for _, marshalType := range strings.Split(strings.TrimSuffix(name[index+1:], "]"), "^") {
+		if marshalType == "" {
+			continue
+		}
+
+		argumentTypes = append(argumentTypes, parseMarshalType(marshalType))
+	}
+
+	return functionSignature(name[:index], argumentTypes)
+}
+
+// parseMarshalType converts a marshal class into its CQL type, including the
+// parameters of collections, tuples and user defined types e.g.
+// MapType(UTF8Type,Int32Type) into map<text, int>. Unknown classes are
+// returned as they are.
+func parseMarshalType(marshalType string) string {
+	marshalType = strings.TrimPrefix(marshalType, cassandraMarshalPrefix)
+
+	index := strings.Index(marshalType, "(")
+
+	if index < 0 || !strings.HasSuffix(marshalType, ")") {
+		if cqlType, ok := marshalTypeToCQLType[marshalType]; ok {
+			return cqlType
+		}
+
+		return marshalType
+	}
+
+	parameters := splitMarshalParameters(marshalType[index+1 : len(marshalType)-1])
+
+	switch class := marshalType[:index]; {
+	case class == "ListType" && len(parameters) == 1:
+		return fmt.Sprintf("list<%s>", parseMarshalType(parameters[0]))
+	case class == "SetType" && len(parameters) == 1:
+		return fmt.Sprintf("set<%s>", parseMarshalType(parameters[0]))
+	case class == "MapType" && len(parameters) == 2:
+		return fmt.Sprintf("map<%s, %s>", parseMarshalType(parameters[0]), parseMarshalType(parameters[1]))
+	case class == "FrozenType" && len(parameters) == 1:
+		return fmt.Sprintf("frozen<%s>", parseMarshalType(parameters[0]))
+	case class == "ReversedType" && len(parameters) == 1:
+		return parseMarshalType(parameters[0])
+	case class == "TupleType" && len(parameters) > 0:
+		elementTypes := make([]string, 0, len(parameters))
+
+		for _, parameter := range parameters {
+			elementTypes = append(elementTypes, parseMarshalType(parameter))
+		}
+
+		return fmt.Sprintf("tuple<%s>", strings.Join(elementTypes, ", "))
+	case class == "UserType" && len(parameters) > 1:
+		// the keyspace is followed by the hex encoded name of the type and
+		// its fields, functions can only take types of their own keyspace
+		typeName, err := hex.DecodeString(parameters[1])
+
+		if err != nil {
+			return marshalType
+		}
+
+		if unquotedTypeNameRegex.MatchString(string(typeName)) {
+			return string(typeName)
+		}
+
+		return cqlIdentifier(string(typeName))
+	}
+
+	return marshalType
+}
+
+// splitMarshalParameters splits the parameters of a marshal class on the
+// commas which are not nested in the parameters of another class
+func splitMarshalParameters(parameters string) []string {
+	var (
+		split []string
+		depth int
+		start int
+	)
+
+	for index, character := range parameters {
+		switch character {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				split = append(split, parameters[start:index])
+				start = index + 1
+			}
+		}
+	}
+
+	return append(split, parameters[start:])
+}
+
+// parseRoleResource converts a resource name from system_auth.role_permissions
+// e.g. data/keyspace/table into a permission without privileges
+func parseRoleResource(resource string) (*RolePermission, bool) {
+	parts := strings.SplitN(resource, "/", 3)
+
+	switch {
+	case parts[0] == "data" && len(parts) == 1:
+		return &RolePermission{ResourceType: resourceAllKeyspaces}, true
+	case parts[0] == "data" && len(parts) == 2:
+		return &RolePermission{ResourceType: resourceKeyspace, Keyspace: parts[1]}, true
+	case parts[0] == "data" && len(parts) == 3:
+		return &RolePermission{ResourceType: resourceTable, Keyspace: parts[1], Identifier: parts[2]}, true
+	case parts[0] == "roles" && len(parts) == 1:
+		return &RolePermission{ResourceType: resourceAllRoles}, true
+	case parts[0] == "roles" && len(parts) == 2:
+		return &RolePermission{ResourceType: resourceRole, Identifier: parts[1]}, true
+	case parts[0] == "functions" && len(parts) == 1:
+		return &RolePermission{ResourceType: resourceAllFunctions}, true
+	case parts[0] == "functions" && len(parts) == 2:
+		return &RolePermission{ResourceType: resourceAllFunctionsInKeyspace, Keyspace: parts[1]}, true
+	case parts[0] == "functions" && len(parts) == 3:
+		return &RolePermission{ResourceType: resourceFunction, Keyspace: parts[1], Identifier: parseFunctionResourceName(parts[2])}, true
+	case parts[0] == "mbean" && len(parts) == 1:
+		return &RolePermission{ResourceType: resourceAllMbeans}, true
+	case parts[0] == "mbean":
+		return &RolePermission{ResourceType: resourceMbean, Identifier: strings.Join(parts[1:], "/")}, true
+	}
+
+	return nil, false
+}
+
+// readRolePermissions returns the permissions granted directly to the role,
+// permissions inherited through other roles are not included
+func readRolePermissions(meta interface{}, grantee string, consistency gocql.Consistency) ([]*RolePermission, error) {
+	session, sessionCreationError := meta.(*Client).Session()
+
+	if sessionCreationError != nil {
+		return nil, sessionCreationError
+	}
+
+	var (
+		resource    string
+		privileges  []string
+		permissions []*RolePermission
+	)
+
+	iter := session.Query(`SELECT resource, permissions FROM system_auth.role_permissions WHERE role = ?`, grantee).Consistency(consistency).Iter()
+
+	for iter.Scan(&resource, &privileges) {
+		permission, ok := parseRoleResource(resource)
+
+		if !ok {
+			log.Printf("[WARN] Ignoring permissions of %s on unsupported resource %s", grantee, resource)
+
+			continue
+		}
+
+		for _, privilege := range privileges {
+			permission.Privileges = append(permission.Privileges, strings.ToLower(privilege))
+		}
+
+		sort.Strings(permission.Privileges)
+
+		permissions = append(permissions, permission)
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+func grantsByKey(permissions []*RolePermission, grantee string) map[string]*Grant {
+	grants := make(map[string]*Grant)
+
+	for _, permission := range permissions {
+		for _, grant := range permission.Grants(grantee) {
+			grants[permission.Key()+grantImportIDSeparator+grant.Privilege] = grant
+		}
+	}
+
+	return grants
+}
+
+func sortedGrantKeys(grants map[string]*Grant) []string {
+	keys := make([]string, 0, len(grants))
+
+	for key := range grants {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// applyRolePermissions revokes every privilege of the grantee on the cluster
+// which is not in desired, then grants the desired privileges it is missing
+func applyRolePermissions(meta interface{}, grantee string, desired []*RolePermission, consistency gocql.Consistency) error {
+	actual, err := readRolePermissions(meta, grantee, consistency)
+
+	if err != nil {
+		return err
+	}
+
+	actualGrants := grantsByKey(actual, grantee)
+	desiredGrants := grantsByKey(desired, grantee)
+
+	for _, key := range sortedGrantKeys(actualGrants) {
+		if _, ok := desiredGrants[key]; !ok {
+			if err := executeGrantTemplate(meta, templateDelete, actualGrants[key]); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, key := range sortedGrantKeys(desiredGrants) {
+		if _, ok := actualGrants[key]; !ok {
+			if err := executeGrantTemplate(meta, templateCreate, desiredGrants[key]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func resourceRoleGrantsImport(d *schema.ResourceData, meta interface{}) ([]*schema.ResourceData, error) {
+	grantee := unquoteIdentifier(d.Id())
+
+	d.SetId(grantee)
+	d.Set(identifierGrantee, grantee)
+
+	return []*schema.ResourceData{d}, nil
+}
+
+func resourceRoleGrantsExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	grantee := getIdentifier(d, identifierGrantee)
+
+	session, sessionCreationError := meta.(*Client).Session()
+
+	if sessionCreationError != nil {
+		return false, sessionCreationError
+	}
+
+	role, _, _, _, err := readRole(session, grantee, authConsistency(d))
+
+	if err != nil {
+		return false, err
+	}
+
+	return role != "", nil
+}
+
+func resourceRoleGrantsCreate(d *schema.ResourceData, meta interface{}) error {
+	grantee := getIdentifier(d, identifierGrantee)
+
+	permissions, err := parsePermissions(d)
+
+	if err != nil {
+		return err
+	}
+
+	if err := applyRolePermissions(meta, grantee, permissions, authConsistency(d)); err != nil {
+		return err
+	}
+
+	d.SetId(grantee)
+
+	return resourceRoleGrantsRead(d, meta)
+}
+
+func resourceRoleGrantsRead(d *schema.ResourceData, meta interface{}) error {
+	grantee := getIdentifier(d, identifierGrantee)
+
+	actual, err := readRolePermissions(meta, grantee, authConsistency(d))
+
+	if err != nil {
+		return err
+	}
+
+	configured := rolePermissionsFromSet(d.Get(identifierPermission).(*schema.Set))
+
+	configuredByKey := make(map[string]*RolePermission)
+
+	for _, permission := range configured {
+		configuredByKey[permission.Key()] = permission
+	}
+
+	rawPermissions := make([]interface{}, 0, len(actual))
+
+	for _, permission := range actual {
+		privileges := permission.Privileges
+
+		// keep the resource as it was written, and all when every privilege
+		// it expands to is granted
+		if configuredPermission, ok := configuredByKey[permission.Key()]; ok {
+			permission.ResourceType = configuredPermission.ResourceType
+			permission.Identifier = configuredPermission.Identifier
+
+			if grantsCoverAll(configuredPermission, permission) {
+				privileges = []string{privilegeAll}
+			}
+		}
+
+		rawPrivileges := make([]interface{}, 0, len(privileges))
+
+		for _, privilege := range privileges {
+			rawPrivileges = append(rawPrivileges, privilege)
+		}
+
+		rawPermissions = append(rawPermissions, map[string]interface{}{
+			identifierResourceType: permission.ResourceType,
+			identifierKeyspaceName: permission.Keyspace,
+			identifierIdentifier:   permission.Identifier,
+			identifierPrivileges:   schema.NewSet(schema.HashString, rawPrivileges),
+		})
+	}
+
+	d.Set(identifierPermission, rawPermissions)
+
+	return nil
+}
+
+// grantsCoverAll reports whether the configured permission asks for all and
+// the actual permission holds exactly the privileges all expands to
+func grantsCoverAll(configured *RolePermission, actual *RolePermission) bool {
+	if len(configured.Privileges) != 1 || configured.Privileges[0] != privilegeAll {
+		return false
+	}
+
+	applicable := applicablePrivileges(configured.ResourceType)
+
+	sort.Strings(applicable)
+
+	return strings.Join(applicable, ",") == strings.Join(actual.Privileges, ",")
+}
+
+func resourceRoleGrantsUpdate(d *schema.ResourceData, meta interface{}) error {
+	grantee := getIdentifier(d, identifierGrantee)
+
+	permissions, err := parsePermissions(d)
+
+	if err != nil {
+		return err
+	}
+
+	if err := applyRolePermissions(meta, grantee, permissions, authConsistency(d)); err != nil {
+		return err
+	}
+
+	return resourceRoleGrantsRead(d, meta)
+}
+
+func resourceRoleGrantsDelete(d *schema.ResourceData, meta interface{}) error {
+	grantee := getIdentifier(d, identifierGrantee)
+
+	return applyRolePermissions(meta, grantee, nil, authConsistency(d))
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestParseFunctionResourceName(t *testing.T) {
+	cases := []struct {
+		name     string
+		expected string
+	}{
+		{"now[]", "now()"},
+		{"double_it[org.apache.cassandra.db.marshal.Int32Type]", "double_it(int)"},
+		{"concat[org.apache.cassandra.db.marshal.UTF8Type^org.apache.cassandra.db.marshal.UTF8Type]", "concat(text, text)"},
+		{"total[org.apache.cassandra.db.marshal.ListType(org.apache.cassandra.db.marshal.Int32Type)]", "total(list<int>)"},
+		{"total[org.apache.cassandra.db.marshal.FrozenType(org.apache.cassandra.db.marshal.SetType(org.apache.cassandra.db.marshal.UUIDType))]", "total(frozen<set<uuid>>)"},
+		{"lookup[org.apache.cassandra.db.marshal.MapType(org.apache.cassandra.db.marshal.UTF8Type,org.apache.cassandra.db.marshal.ListType(org.apache.cassandra.db.marshal.LongType))^org.apache.cassandra.db.marshal.UTF8Type]", "lookup(map<text, list<bigint>>, text)"},
+		{"pair[org.apache.cassandra.db.marshal.TupleType(org.apache.cassandra.db.marshal.Int32Type,org.apache.cassandra.db.marshal.UTF8Type)]", "pair(tuple<int, text>)"},
+		{"format[org.apache.cassandra.db.marshal.FrozenType(org.apache.cassandra.db.marshal.UserType(ks,61646472657373,737472656574:org.apache.cassandra.db.marshal.UTF8Type))]", "format(frozen<address>)"},
+		{"format[org.apache.cassandra.db.marshal.FrozenType(org.apache.cassandra.db.marshal.UserType(ks,4164647265737320426f6f6b,6e616d65:org.apache.cassandra.db.marshal.UTF8Type))]", `format(frozen<"Address Book">)`},
+		{"custom[com.example.CustomType]", "custom(com.example.CustomType)"},
+	}
+
+	for _, c := range cases {
+		if actual := parseFunctionResourceName(c.name); actual != c.expected {
+			t.Errorf("%s: expected %s, got %s", c.name, c.expected, actual)
+		}
+	}
+}
+
+func TestParseFunctionResourceNameMatchesSignature(t *testing.T) {
+	listed := &RolePermission{ResourceType: resourceFunction, Keyspace: "ks", Identifier: parseFunctionResourceName("lookup[org.apache.cassandra.db.marshal.MapType(org.apache.cassandra.db.marshal.UTF8Type,org.apache.cassandra.db.marshal.Int32Type)]")}
+	configured := &RolePermission{ResourceType: resourceFunction, Keyspace: "ks", Identifier: "lookup(MAP<varchar,int>)"}
+
+	if listed.Key() != configured.Key() {
+		t.Errorf("expected %s to match %s", listed.Key(), configured.Key())
+	}
+}
+
+func TestRolePermissionGrantsAll(t *testing.T) {
+	cases := []struct {
+		resourceType string
+		expected     []string
+	}{
+		{resourceAllKeyspaces, []string{privilegeSelect, privilegeCreate, privilegeAlter, privilegeDrop, privilegeModify, privilegeAuthorize}},
+		{resourceTable, []string{privilegeSelect, privilegeAlter, privilegeDrop, privilegeModify, privilegeAuthorize}},
+		{resourceFunction, []string{privilegeAlter, privilegeDrop, privilegeAuthorize, privilegeExecute}},
+		{resourceAllRoles, []string{privilegeCreate, privilegeAlter, privilegeDrop, privilegeAuthorize, privilegeDescribe}},
+		{resourceRole, []string{privilegeAlter, privilegeDrop, privilegeAuthorize}},
+	}
+
+	for _, c := range cases {
+		permission := &RolePermission{ResourceType: c.resourceType, Privileges: []string{privilegeAll}}
+
+		var privileges []string
+
+		for _, grant := range permission.Grants("app") {
+			privileges = append(privileges, grant.Privilege)
+		}
+
+		if !reflect.DeepEqual(privileges, c.expected) {
+			t.Errorf("%s: expected all to grant %v, got %v", c.resourceType, c.expected, privileges)
+		}
+
+		actual := &RolePermission{ResourceType: c.resourceType, Privileges: append([]string(nil), c.expected...)}
+
+		sort.Strings(actual.Privileges)
+
+		if !grantsCoverAll(permission, actual) {
+			t.Errorf("%s: expected %v to be read back as all", c.resourceType, actual.Privileges)
+		}
+	}
+}
+
+func TestValidFunctionName(t *testing.T) {
+	cases := []struct {
+		name  string
+		valid bool
+	}{
+		{"now", true},
+		{"now()", true},
+		{"concat(text, text)", true},
+		{"lookup(map<text, list<bigint>>, text)", true},
+		{`format(frozen<"Address Book">)`, true},
+		{`format(frozen<"Book, ""Old""">, int)`, true},
+		{`format(frozen<"Address>)`, false},
+		{`format(frozen<"">)`, false},
+		{`"now"()`, false},
+		{"total(int", false},
+	}
+
+	for _, c := range cases {
+		if valid := validFunctionNameRegex.MatchString(c.name); valid != c.valid {
+			t.Errorf("%s: expected valid to be %t, got %t", c.name, c.valid, valid)
+		}
+	}
+}
+
+func TestFunctionKeyQuotedType(t *testing.T) {
+	listed := &RolePermission{ResourceType: resourceFunction, Keyspace: "ks", Identifier: parseFunctionResourceName("format[org.apache.cassandra.db.marshal.FrozenType(org.apache.cassandra.db.marshal.UserType(ks,426f6f6b2c204f6c64,6e616d65:org.apache.cassandra.db.marshal.UTF8Type))^org.apache.cassandra.db.marshal.Int32Type]")}
+	configured := &RolePermission{ResourceType: resourceFunction, Keyspace: "ks", Identifier: `format(FROZEN<"Book, Old">,INT)`}
+
+	if listed.Key() != configured.Key() {
+		t.Errorf("expected %s to match %s", listed.Key(), configured.Key())
+	}
+}
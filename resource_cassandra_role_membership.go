@@ -0,0 +1,167 @@
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const (
+	identifierRole   = "role"
+	identifierMember = "member"
+)
+
+// RoleMembership represents a role granted to another role
+type RoleMembership struct {
+	Role   string
+	Member string
+}
+
+func resourceCassandraRoleMembership() *schema.Resource {
+	return &schema.Resource{
+		Create: resourceRoleMembershipCreate,
+		Read:   resourceRoleMembershipRead,
+		Update: resourceRoleMembershipUpdate,
+		Delete: resourceRoleMembershipDelete,
+		Exists: resourceRoleMembershipExists,
+		Schema: map[string]*schema.Schema{
+			identifierConsistency: authConsistencySchema(),
+			identifierRole: &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "role being granted",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "role", validRoleRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			identifierMember: &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "role the granted role is given to",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "member", validRoleRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+		},
+	}
+}
+
+func parseRoleMembershipData(d *schema.ResourceData) (*RoleMembership, error) {
+	role := getIdentifier(d, identifierRole)
+	member := getIdentifier(d, identifierMember)
+
+	if role == member {
+		return nil, fmt.Errorf("role %s cannot be granted to itself", role)
+	}
+
+	return &RoleMembership{role, member}, nil
+}
+
+func readRoleMembership(session *gocql.Session, membership *RoleMembership, consistency gocql.Consistency) (bool, error) {
+	iter := session.Query(`SELECT role, member FROM system_auth.role_members WHERE role = ? AND member = ?`, membership.Role, membership.Member).Consistency(consistency).Iter()
+
+	rowCount := iter.NumRows()
+
+	iterError := iter.Close()
+
+	log.Printf("read role membership query returned %d", rowCount)
+
+	return rowCount > 0, iterError
+}
+
+func resourceRoleMembershipExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	membership, err := parseRoleMembershipData(d)
+
+	if err != nil {
+		return false, err
+	}
+
+	session, sessionCreationError := meta.(*Client).Session()
+
+	if sessionCreationError != nil {
+		return false, sessionCreationError
+	}
+
+	return readRoleMembership(session, membership, authConsistency(d))
+}
+
+func resourceRoleMembershipCreate(d *schema.ResourceData, meta interface{}) error {
+	membership, err := parseRoleMembershipData(d)
+
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`GRANT %s TO %s`, cqlIdentifier(membership.Role), cqlIdentifier(membership.Member))
+
+	log.Printf("Executing query %v", query)
+
+	err = meta.(*Client).ExecStatement(query)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(hash(fmt.Sprintf("%+v", membership)))
+
+	return nil
+}
+
+func resourceRoleMembershipRead(d *schema.ResourceData, meta interface{}) error {
+	membership, err := parseRoleMembershipData(d)
+
+	if err != nil {
+		return err
+	}
+
+	session, sessionCreationError := meta.(*Client).Session()
+
+	if sessionCreationError != nil {
+		return sessionCreationError
+	}
+
+	exists, err := readRoleMembership(session, membership, authConsistency(d))
+
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		log.Printf("role %s is no longer granted to %s, removing from state", membership.Role, membership.Member)
+
+		d.SetId("")
+
+		return nil
+	}
+
+	d.Set(identifierRole, membership.Role)
+	d.Set(identifierMember, membership.Member)
+
+	return nil
+}
+
+func resourceRoleMembershipDelete(d *schema.ResourceData, meta interface{}) error {
+	membership, err := parseRoleMembershipData(d)
+
+	if err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf(`REVOKE %s FROM %s`, cqlIdentifier(membership.Role), cqlIdentifier(membership.Member))
+
+	log.Printf("Executing query %v", query)
+
+	return meta.(*Client).ExecStatement(query)
+}
+
+// resourceRoleMembershipUpdate only refreshes the membership, the consistency
+// level is the one attribute which changes in place
+func resourceRoleMembershipUpdate(d *schema.ResourceData, meta interface{}) error {
+	return resourceRoleMembershipRead(d, meta)
+}
@@ -0,0 +1,651 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/hashcode"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const (
+	columnNameLiteralPattern = `^[a-zA-Z0-9][a-zA-Z0-9_]{0,47}$`
+
+	clusteringOrderAsc  = "ASC"
+	clusteringOrderDesc = "DESC"
+
+	cassandraClassPrefix = "org.apache.cassandra."
+)
+
+var (
+	columnNameRegex, _ = regexp.Compile(columnNameLiteralPattern)
+	varcharRegex, _    = regexp.Compile(`\bvarchar\b`)
+
+	tableMapOptions = []string{"compaction", "compression", "caching"}
+	tableIntOptions = []string{"gc_grace_seconds", "default_time_to_live"}
+)
+
+func resourceCassandraTable() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceTableCreate,
+		Read:          resourceTableRead,
+		Update:        resourceTableUpdate,
+		Delete:        resourceTableDelete,
+		Exists:        resourceTableExists,
+		CustomizeDiff: resourceTableCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"keyspace": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the keyspace the table belongs to",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "keyspace", keyspaceRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the table",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "table name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"column": &schema.Schema{
+				Type:        schema.TypeSet,
+				Required:    true,
+				MinItems:    1,
+				Description: "Columns of the table, including the columns that make up the primary key",
+				Set:         tableColumnHash,
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Name of the column",
+							ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+								return validIdentifier(i, s, "column name", columnNameRegex)
+							},
+						},
+						"type": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "CQL type of the column e.g. text, int, frozen<list<text>>",
+							DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+								return normalizeColumnType(old) == normalizeColumnType(new)
+							},
+						},
+						"static": &schema.Schema{
+							Type:        schema.TypeBool,
+							Optional:    true,
+							Default:     false,
+							Description: "Whether the column is static - only valid on tables with clustering keys",
+						},
+					},
+				},
+			},
+			"partition_keys": &schema.Schema{
+				Type:        schema.TypeList,
+				Required:    true,
+				ForceNew:    true,
+				MinItems:    1,
+				Description: "Ordered list of column names making up the partition key",
+				Elem: &schema.Schema{
+					Type: schema.TypeString,
+				},
+			},
+			"clustering_key":   clusteringKeySchema("Ordered list of clustering columns"),
+			"compaction":       tableMapOptionSchema("Compaction options, must include class when set"),
+			"compression":      tableMapOptionSchema("Compression options"),
+			"caching":          tableMapOptionSchema("Caching options e.g. keys and rows_per_partition"),
+			"gc_grace_seconds": gcGraceSecondsSchema(),
+			"default_time_to_live": &schema.Schema{
+				Type:        schema.TypeInt,
+				Optional:    true,
+				Computed:    true,
+				Description: "Default TTL in seconds for data written to the table",
+			},
+		},
+	}
+}
+
+// clusteringKeySchema is the ordered list of clustering columns of a table or
+// materialized view
+func clusteringKeySchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeList,
+		Optional:    true,
+		ForceNew:    true,
+		Description: description,
+		Elem: &schema.Resource{
+			Schema: map[string]*schema.Schema{
+				"name": &schema.Schema{
+					Type:        schema.TypeString,
+					Required:    true,
+					ForceNew:    true,
+					Description: "Name of the clustering column",
+				},
+				"order": &schema.Schema{
+					Type:        schema.TypeString,
+					Optional:    true,
+					ForceNew:    true,
+					Default:     clusteringOrderAsc,
+					Description: fmt.Sprintf("Clustering order - must be one of %s or %s", clusteringOrderAsc, clusteringOrderDesc),
+					ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+						order := i.(string)
+
+						if order != clusteringOrderAsc && order != clusteringOrderDesc {
+							errors = append(errors, fmt.Errorf("%s: invalid clustering order - must be one of %s or %s", order, clusteringOrderAsc, clusteringOrderDesc))
+						}
+
+						return
+					},
+				},
+			},
+		},
+	}
+}
+
+// tableMapOptionSchema is one of the map options in tableMapOptions, values
+// not set are read back from the cluster
+func tableMapOptionSchema(description string) *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeMap,
+		Optional:    true,
+		Computed:    true,
+		Description: description,
+		Elem: &schema.Schema{
+			Type: schema.TypeString,
+		},
+	}
+}
+
+func gcGraceSecondsSchema() *schema.Schema {
+	return &schema.Schema{
+		Type:        schema.TypeInt,
+		Optional:    true,
+		Computed:    true,
+		Description: "Seconds to wait before garbage collecting tombstones",
+	}
+}
+
+// TableColumn represents a column in a Cassandra table
+type TableColumn struct {
+	Name   string
+	Type   string
+	Static bool
+}
+
+// ClusteringKey represents a clustering column and its order
+type ClusteringKey struct {
+	Name  string
+	Order string
+}
+
+// normalizeColumnType returns a CQL type the way Cassandra prints it in
+// system_schema e.g. "MAP<varchar,int>" becomes "map<text, int>". Quoted names of user
+// defined types are case sensitive and are kept as they are
+func normalizeColumnType(columnType string) string {
+	segments := strings.Split(columnType, `"`)
+
+	// segments at even positions are outside of quoted identifiers
+	for index := 0; index < len(segments); index += 2 {
+		normalized := strings.ToLower(strings.Replace(segments[index], " ", "", -1))
+		normalized = strings.Replace(normalized, ",", ", ", -1)
+
+		segments[index] = varcharRegex.ReplaceAllString(normalized, "text")
+	}
+
+	return strings.Join(segments, `"`)
+}
+
+func tableColumnHash(v interface{}) int {
+	column := v.(map[string]interface{})
+
+	return hashcode.String(fmt.Sprintf("%s-%s-%t", column["name"].(string), normalizeColumnType(column["type"].(string)), column["static"].(bool)))
+}
+
+func tableColumnsFromSet(set *schema.Set) map[string]TableColumn {
+	columns := make(map[string]TableColumn)
+
+	for _, raw := range set.List() {
+		column := raw.(map[string]interface{})
+		name := column["name"].(string)
+
+		columns[name] = TableColumn{name, column["type"].(string), column["static"].(bool)}
+	}
+
+	return columns
+}
+
+func tablePartitionKeys(raw []interface{}) []string {
+	keys := make([]string, 0, len(raw))
+
+	for _, value := range raw {
+		keys = append(keys, value.(string))
+	}
+
+	return keys
+}
+
+func tableClusteringKeys(raw []interface{}) []ClusteringKey {
+	keys := make([]ClusteringKey, 0, len(raw))
+
+	for _, value := range raw {
+		key := value.(map[string]interface{})
+
+		keys = append(keys, ClusteringKey{key["name"].(string), key["order"].(string)})
+	}
+
+	return keys
+}
+
+func sortedColumnNames(columns map[string]TableColumn) []string {
+	names := make([]string, 0, len(columns))
+
+	for name := range columns {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func generateTableOptionMap(options map[string]interface{}) string {
+	values := make(map[string]string)
+
+	for key, value := range options {
+		values[key] = value.(string)
+	}
+
+	return cqlStringMap(values)
+}
+
+// generatePrimaryKey returns the PRIMARY KEY clause and, when there are
+// clustering columns, the CLUSTERING ORDER BY option of a table or view
+func generatePrimaryKey(partitionKeys []string, clusteringKeys []ClusteringKey) (string, string) {
+	primaryKey := []string{fmt.Sprintf("(%s)", cqlIdentifiers(partitionKeys))}
+	clusteringOrder := make([]string, 0, len(clusteringKeys))
+
+	for _, key := range clusteringKeys {
+		primaryKey = append(primaryKey, cqlIdentifier(key.Name))
+		clusteringOrder = append(clusteringOrder, fmt.Sprintf("%s %s", cqlIdentifier(key.Name), key.Order))
+	}
+
+	primaryKeyClause := fmt.Sprintf(`PRIMARY KEY (%s)`, strings.Join(primaryKey, ", "))
+
+	if len(clusteringOrder) == 0 {
+		return primaryKeyClause, ""
+	}
+
+	return primaryKeyClause, fmt.Sprintf("CLUSTERING ORDER BY (%s)", strings.Join(clusteringOrder, ", "))
+}
+
+func generateCreateTableQueryString(keyspace string, name string, columns map[string]TableColumn, partitionKeys []string, clusteringKeys []ClusteringKey, options []string) string {
+	var buffer bytes.Buffer
+
+	buffer.WriteString(fmt.Sprintf(`CREATE TABLE %s (`, cqlQualifiedName(keyspace, name)))
+
+	for _, columnName := range sortedColumnNames(columns) {
+		column := columns[columnName]
+
+		buffer.WriteString(fmt.Sprintf(`%s %s`, cqlIdentifier(column.Name), column.Type))
+
+		if column.Static {
+			buffer.WriteString(" STATIC")
+		}
+
+		buffer.WriteString(", ")
+	}
+
+	primaryKey, clusteringOrder := generatePrimaryKey(partitionKeys, clusteringKeys)
+
+	buffer.WriteString(fmt.Sprintf(`%s)`, primaryKey))
+
+	if clusteringOrder != "" {
+		options = append([]string{clusteringOrder}, options...)
+	}
+
+	if len(options) > 0 {
+		buffer.WriteString(fmt.Sprintf(` WITH %s`, strings.Join(options, " AND ")))
+	}
+
+	query := buffer.String()
+
+	log.Println("query", query)
+
+	return query
+}
+
+// tableOptions returns the WITH clauses for the given table options that are
+// set, or only those that changed when onlyChanged is true
+func tableOptions(d *schema.ResourceData, intOptions []string, onlyChanged bool) []string {
+	var options []string
+
+	for _, option := range tableMapOptions {
+		if onlyChanged && !d.HasChange(option) {
+			continue
+		}
+
+		value := d.Get(option).(map[string]interface{})
+
+		if len(value) > 0 {
+			options = append(options, fmt.Sprintf("%s = %s", option, generateTableOptionMap(value)))
+		}
+	}
+
+	for _, option := range intOptions {
+		if onlyChanged && !d.HasChange(option) {
+			continue
+		}
+
+		if value, ok := d.GetOkExists(option); ok {
+			options = append(options, fmt.Sprintf("%s = %d", option, value.(int)))
+		}
+	}
+
+	return options
+}
+
+func resourceTableCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if !d.NewValueKnown("column") || !d.NewValueKnown("partition_keys") || !d.NewValueKnown("clustering_key") {
+		return nil
+	}
+
+	oldRaw, newRaw := d.GetChange("column")
+
+	oldColumns := tableColumnsFromSet(oldRaw.(*schema.Set))
+	newColumns := tableColumnsFromSet(newRaw.(*schema.Set))
+
+	keyColumns := make(map[string]bool)
+
+	for _, key := range tablePartitionKeys(d.Get("partition_keys").([]interface{})) {
+		keyColumns[key] = true
+	}
+
+	for _, key := range tableClusteringKeys(d.Get("clustering_key").([]interface{})) {
+		keyColumns[key.Name] = true
+	}
+
+	for key := range keyColumns {
+		column, ok := newColumns[key]
+
+		if !ok {
+			return fmt.Errorf("primary key column %s must be declared as a column", key)
+		}
+
+		if column.Static {
+			return fmt.Errorf("primary key column %s cannot be static", key)
+		}
+	}
+
+	for name, newColumn := range newColumns {
+		oldColumn, ok := oldColumns[name]
+
+		if ok && oldColumn.Static != newColumn.Static {
+			return fmt.Errorf("changing whether column %s is static is not supported", name)
+		}
+
+		if !ok || normalizeColumnType(oldColumn.Type) == normalizeColumnType(newColumn.Type) {
+			continue
+		}
+
+		if keyColumns[name] {
+			return d.ForceNew("column")
+		}
+
+		return fmt.Errorf("changing the type of column %s from %s to %s is not supported", name, oldColumn.Type, newColumn.Type)
+	}
+
+	return nil
+}
+
+func resourceTableExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	session, err := meta.(*Client).Session()
+
+	if err != nil {
+		return false, err
+	}
+
+	// a table whose keyspace was dropped is not found either
+	iter := session.Query(`SELECT table_name FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?`, keyspace, name).Iter()
+
+	exists := iter.Scan(nil)
+
+	if err := iter.Close(); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+func resourceTableCreate(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	columns := tableColumnsFromSet(d.Get("column").(*schema.Set))
+	partitionKeys := tablePartitionKeys(d.Get("partition_keys").([]interface{}))
+	clusteringKeys := tableClusteringKeys(d.Get("clustering_key").([]interface{}))
+
+	query := generateCreateTableQueryString(keyspace, name, columns, partitionKeys, clusteringKeys, tableOptions(d, tableIntOptions, false))
+
+	err := meta.(*Client).ExecSchemaChange(query)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s.%s", keyspace, name))
+
+	return resourceTableRead(d, meta)
+}
+
+// normalizeTableOption shortens built in class names and, when the option was
+// configured, drops the server side defaults that were not asked for
+func normalizeTableOption(configured map[string]interface{}, actual map[string]string) map[string]string {
+	normalized := make(map[string]string)
+
+	for key, value := range actual {
+		if len(configured) > 0 {
+			if _, ok := configured[key]; !ok {
+				continue
+			}
+		}
+
+		if key == "class" && strings.HasPrefix(value, cassandraClassPrefix) {
+			value = value[strings.LastIndex(value, ".")+1:]
+		}
+
+		normalized[key] = value
+	}
+
+	return normalized
+}
+
+// readTableColumns returns the columns of a table or materialized view with
+// its partition and clustering keys in order, as stored in system_schema.columns
+func readTableColumns(session *gocql.Session, keyspace string, name string) ([]TableColumn, []string, []ClusteringKey, error) {
+	var (
+		columns        []TableColumn
+		partitionKeys  []string
+		clusteringKeys []ClusteringKey
+
+		columnName      string
+		columnType      string
+		kind            string
+		position        int
+		clusteringOrder string
+	)
+
+	partitionKeyPositions := make(map[int]string)
+	clusteringKeyPositions := make(map[int]ClusteringKey)
+
+	iter := session.Query(`SELECT column_name, type, kind, position, clustering_order FROM system_schema.columns WHERE keyspace_name = ? AND table_name = ?`, keyspace, name).Iter()
+
+	for iter.Scan(&columnName, &columnType, &kind, &position, &clusteringOrder) {
+		columns = append(columns, TableColumn{columnName, columnType, kind == "static"})
+
+		switch kind {
+		case "partition_key":
+			partitionKeyPositions[position] = columnName
+		case "clustering":
+			clusteringKeyPositions[position] = ClusteringKey{columnName, strings.ToUpper(clusteringOrder)}
+		}
+	}
+
+	if err := iter.Close(); err != nil {
+		return nil, nil, nil, err
+	}
+
+	for position := 0; position < len(partitionKeyPositions); position++ {
+		partitionKeys = append(partitionKeys, partitionKeyPositions[position])
+	}
+
+	for position := 0; position < len(clusteringKeyPositions); position++ {
+		clusteringKeys = append(clusteringKeys, clusteringKeyPositions[position])
+	}
+
+	return columns, partitionKeys, clusteringKeys, nil
+}
+
+// readTable sets the columns, keys and options of the table from
+// system_schema, it is shared by the cassandra_table resource and data source
+func readTable(d *schema.ResourceData, client *Client, keyspace string, name string) error {
+	session, err := client.Session()
+
+	if err != nil {
+		return err
+	}
+
+	var (
+		compaction        map[string]string
+		compression       map[string]string
+		caching           map[string]string
+		gcGraceSeconds    int
+		defaultTimeToLive int
+	)
+
+	iter := session.Query(`SELECT compaction, compression, caching, gc_grace_seconds, default_time_to_live FROM system_schema.tables WHERE keyspace_name = ? AND table_name = ?`, keyspace, name).Iter()
+
+	found := iter.Scan(&compaction, &compression, &caching, &gcGraceSeconds, &defaultTimeToLive)
+
+	if err := iter.Close(); err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("table %s does not exist in keyspace %s", name, keyspace)
+	}
+
+	tableColumns, partitionKeys, tableClusteringKeys, err := readTableColumns(session, keyspace, name)
+
+	if err != nil {
+		return err
+	}
+
+	columns := make([]interface{}, 0, len(tableColumns))
+
+	for _, column := range tableColumns {
+		columns = append(columns, map[string]interface{}{
+			"name":   column.Name,
+			"type":   column.Type,
+			"static": column.Static,
+		})
+	}
+
+	clusteringKeys := make([]map[string]interface{}, 0, len(tableClusteringKeys))
+
+	for _, key := range tableClusteringKeys {
+		clusteringKeys = append(clusteringKeys, map[string]interface{}{
+			"name":  key.Name,
+			"order": key.Order,
+		})
+	}
+
+	d.Set("column", schema.NewSet(tableColumnHash, columns))
+	d.Set("partition_keys", partitionKeys)
+	d.Set("clustering_key", clusteringKeys)
+	d.Set("compaction", normalizeTableOption(d.Get("compaction").(map[string]interface{}), compaction))
+	d.Set("compression", normalizeTableOption(d.Get("compression").(map[string]interface{}), compression))
+	d.Set("caching", normalizeTableOption(d.Get("caching").(map[string]interface{}), caching))
+	d.Set("gc_grace_seconds", gcGraceSeconds)
+	d.Set("default_time_to_live", defaultTimeToLive)
+
+	return nil
+}
+
+func resourceTableRead(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	return readTable(d, meta.(*Client), keyspace, name)
+}
+
+func resourceTableUpdate(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	var queries []string
+
+	if d.HasChange("column") {
+		oldRaw, newRaw := d.GetChange("column")
+
+		oldColumns := tableColumnsFromSet(oldRaw.(*schema.Set))
+		newColumns := tableColumnsFromSet(newRaw.(*schema.Set))
+
+		for _, columnName := range sortedColumnNames(oldColumns) {
+			if _, ok := newColumns[columnName]; !ok {
+				queries = append(queries, fmt.Sprintf(`ALTER TABLE %s DROP %s`, cqlQualifiedName(keyspace, name), cqlIdentifier(columnName)))
+			}
+		}
+
+		for _, columnName := range sortedColumnNames(newColumns) {
+			if _, ok := oldColumns[columnName]; ok {
+				continue
+			}
+
+			column := newColumns[columnName]
+			query := fmt.Sprintf(`ALTER TABLE %s ADD %s %s`, cqlQualifiedName(keyspace, name), cqlIdentifier(column.Name), column.Type)
+
+			if column.Static {
+				query += " STATIC"
+			}
+
+			queries = append(queries, query)
+		}
+	}
+
+	options := tableOptions(d, tableIntOptions, true)
+
+	if len(options) > 0 {
+		queries = append(queries, fmt.Sprintf(`ALTER TABLE %s WITH %s`, cqlQualifiedName(keyspace, name), strings.Join(options, " AND ")))
+	}
+
+	for _, query := range queries {
+		log.Println("query", query)
+
+		if err := meta.(*Client).ExecSchemaChange(query); err != nil {
+			return err
+		}
+	}
+
+	return resourceTableRead(d, meta)
+}
+
+func resourceTableDelete(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	return meta.(*Client).ExecSchemaChange(fmt.Sprintf(`DROP TABLE %s`, cqlQualifiedName(keyspace, name)))
+}
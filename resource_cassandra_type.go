@@ -0,0 +1,334 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+func resourceCassandraType() *schema.Resource {
+	return &schema.Resource{
+		Create:        resourceTypeCreate,
+		Read:          resourceTypeRead,
+		Update:        resourceTypeUpdate,
+		Delete:        resourceTypeDelete,
+		Exists:        resourceTypeExists,
+		CustomizeDiff: resourceTypeCustomizeDiff,
+		Schema: map[string]*schema.Schema{
+			"keyspace": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the keyspace the type belongs to",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "keyspace", keyspaceRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"name": &schema.Schema{
+				Type:        schema.TypeString,
+				Required:    true,
+				ForceNew:    true,
+				Description: "Name of the user defined type",
+				ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+					return validQuotedIdentifier(i, s, "type name", validTableNameRegex)
+				},
+				StateFunc: identifierStateFunc,
+			},
+			"field": &schema.Schema{
+				Type:        schema.TypeList,
+				Required:    true,
+				MinItems:    1,
+				Description: "Ordered fields of the type - new fields must be appended, renaming a field in place issues a RENAME",
+				Elem: &schema.Resource{
+					Schema: map[string]*schema.Schema{
+						"name": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "Name of the field",
+							ValidateFunc: func(i interface{}, s string) (ws []string, errors []error) {
+								return validIdentifier(i, s, "field name", columnNameRegex)
+							},
+						},
+						"type": &schema.Schema{
+							Type:        schema.TypeString,
+							Required:    true,
+							Description: "CQL type of the field",
+							DiffSuppressFunc: func(k, old, new string, d *schema.ResourceData) bool {
+								return normalizeColumnType(old) == normalizeColumnType(new)
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// TypeField represents a field of a Cassandra user defined type
+type TypeField struct {
+	Name string
+	Type string
+}
+
+func typeFieldsFromList(raw []interface{}) []TypeField {
+	fields := make([]TypeField, 0, len(raw))
+
+	for _, value := range raw {
+		field := value.(map[string]interface{})
+
+		fields = append(fields, TypeField{field["name"].(string), field["type"].(string)})
+	}
+
+	return fields
+}
+
+// typeFieldsCanBeAltered reports whether newFields can be reached from
+// oldFields using ALTER TYPE, which only supports appending and renaming.
+// Renaming a field to the name of another existing field, e.g. swapping two
+// fields of the same type, would clash with that field.
+func typeFieldsCanBeAltered(oldFields []TypeField, newFields []TypeField) bool {
+	if len(newFields) < len(oldFields) {
+		return false
+	}
+
+	oldPositions := make(map[string]int, len(oldFields))
+
+	for index, oldField := range oldFields {
+		oldPositions[oldField.Name] = index
+	}
+
+	for index, oldField := range oldFields {
+		if normalizeColumnType(oldField.Type) != normalizeColumnType(newFields[index].Type) {
+			return false
+		}
+
+		if position, ok := oldPositions[newFields[index].Name]; ok && position != index {
+			return false
+		}
+	}
+
+	return true
+}
+
+func resourceTypeCustomizeDiff(d *schema.ResourceDiff, meta interface{}) error {
+	if d.Id() == "" || !d.NewValueKnown("field") || !d.HasChange("field") {
+		return nil
+	}
+
+	oldRaw, newRaw := d.GetChange("field")
+
+	if !typeFieldsCanBeAltered(typeFieldsFromList(oldRaw.([]interface{})), typeFieldsFromList(newRaw.([]interface{}))) {
+		return d.ForceNew("field")
+	}
+
+	return nil
+}
+
+func readType(session *gocql.Session, keyspace string, name string) ([]TypeField, bool, error) {
+	var (
+		fieldNames []string
+		fieldTypes []string
+	)
+
+	iter := session.Query(`SELECT field_names, field_types FROM system_schema.types WHERE keyspace_name = ? AND type_name = ?`, keyspace, name).Iter()
+
+	found := iter.Scan(&fieldNames, &fieldTypes)
+
+	if err := iter.Close(); err != nil {
+		return nil, false, err
+	}
+
+	fields := make([]TypeField, 0, len(fieldNames))
+
+	for index, fieldName := range fieldNames {
+		fields = append(fields, TypeField{fieldName, fieldTypes[index]})
+	}
+
+	return fields, found, nil
+}
+
+// typeReferences lists the table columns and types in the keyspace which still
+// use the named type
+func typeReferences(session *gocql.Session, keyspace string, name string) ([]string, error) {
+	var (
+		references []string
+		tableName  string
+		columnName string
+		columnType string
+		typeName   string
+		fieldTypes []string
+	)
+
+	// the name of a type which is not lower case is quoted where it is used
+	typeRegex, err := regexp.Compile(fmt.Sprintf(`(^|[<,\s])(%s|%s)($|[>,\s])`, regexp.QuoteMeta(name), regexp.QuoteMeta(cqlIdentifier(name))))
+
+	if err != nil {
+		return nil, err
+	}
+
+	columns := session.Query(`SELECT table_name, column_name, type FROM system_schema.columns WHERE keyspace_name = ?`, keyspace).Iter()
+
+	for columns.Scan(&tableName, &columnName, &columnType) {
+		if typeRegex.MatchString(columnType) {
+			references = append(references, fmt.Sprintf("column %s.%s.%s", keyspace, tableName, columnName))
+		}
+	}
+
+	if err := columns.Close(); err != nil {
+		return nil, err
+	}
+
+	types := session.Query(`SELECT type_name, field_types FROM system_schema.types WHERE keyspace_name = ?`, keyspace).Iter()
+
+	for types.Scan(&typeName, &fieldTypes) {
+		for _, fieldType := range fieldTypes {
+			if typeName != name && typeRegex.MatchString(fieldType) {
+				references = append(references, fmt.Sprintf("type %s.%s", keyspace, typeName))
+				break
+			}
+		}
+	}
+
+	if err := types.Close(); err != nil {
+		return nil, err
+	}
+
+	return references, nil
+}
+
+func resourceTypeExists(d *schema.ResourceData, meta interface{}) (b bool, e error) {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return false, sessionCreateError
+	}
+
+	_, found, err := readType(session, keyspace, name)
+
+	return found, err
+}
+
+func resourceTypeCreate(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+	fields := typeFieldsFromList(d.Get("field").([]interface{}))
+
+	definitions := make([]string, 0, len(fields))
+
+	for _, field := range fields {
+		definitions = append(definitions, fmt.Sprintf("%s %s", cqlIdentifier(field.Name), field.Type))
+	}
+
+	query := fmt.Sprintf(`CREATE TYPE %s (%s)`, cqlQualifiedName(keyspace, name), strings.Join(definitions, ", "))
+
+	log.Println("query", query)
+
+	err := meta.(*Client).ExecSchemaChange(query)
+
+	if err != nil {
+		return err
+	}
+
+	d.SetId(fmt.Sprintf("%s.%s", keyspace, name))
+
+	return nil
+}
+
+func resourceTypeRead(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return sessionCreateError
+	}
+
+	fields, found, err := readType(session, keyspace, name)
+
+	if err != nil {
+		return err
+	}
+
+	if !found {
+		return fmt.Errorf("type %s does not exist in keyspace %s", name, keyspace)
+	}
+
+	rawFields := make([]map[string]interface{}, 0, len(fields))
+
+	for _, field := range fields {
+		rawFields = append(rawFields, map[string]interface{}{
+			"name": field.Name,
+			"type": field.Type,
+		})
+	}
+
+	d.Set("field", rawFields)
+
+	return nil
+}
+
+func resourceTypeUpdate(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	oldRaw, newRaw := d.GetChange("field")
+
+	oldFields := typeFieldsFromList(oldRaw.([]interface{}))
+	newFields := typeFieldsFromList(newRaw.([]interface{}))
+
+	if !typeFieldsCanBeAltered(oldFields, newFields) {
+		return fmt.Errorf("fields of type %s.%s can only be appended or renamed to a name no other field has", keyspace, name)
+	}
+
+	var queries []string
+
+	for index, newField := range newFields {
+		if index >= len(oldFields) {
+			queries = append(queries, fmt.Sprintf(`ALTER TYPE %s ADD %s %s`, cqlQualifiedName(keyspace, name), cqlIdentifier(newField.Name), newField.Type))
+		} else if oldFields[index].Name != newField.Name {
+			queries = append(queries, fmt.Sprintf(`ALTER TYPE %s RENAME %s TO %s`, cqlQualifiedName(keyspace, name), cqlIdentifier(oldFields[index].Name), cqlIdentifier(newField.Name)))
+		}
+	}
+
+	for _, query := range queries {
+		log.Println("query", query)
+
+		if err := meta.(*Client).ExecSchemaChange(query); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func resourceTypeDelete(d *schema.ResourceData, meta interface{}) error {
+	keyspace := getIdentifier(d, "keyspace")
+	name := getIdentifier(d, "name")
+
+	session, sessionCreateError := meta.(*Client).Session()
+
+	if sessionCreateError != nil {
+		return sessionCreateError
+	}
+
+	references, err := typeReferences(session, keyspace, name)
+
+	if err != nil {
+		return err
+	}
+
+	if len(references) > 0 {
+		return fmt.Errorf("cannot drop type %s.%s, it is still referenced by %s", keyspace, name, strings.Join(references, ", "))
+	}
+
+	return meta.(*Client).ExecSchemaChange(fmt.Sprintf(`DROP TYPE %s`, cqlQualifiedName(keyspace, name)))
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+const (
+	// error codes of a host which is overloaded or still bootstrapping, the
+	// request can succeed on another host or once the host has caught up
+	cqlErrorCodeOverloaded    = 0x1001
+	cqlErrorCodeBootstrapping = 0x1002
+)
+
+// retryableErrors are returned by gocql when a host did not answer in time or
+// the connection to it was lost
+var retryableErrors = map[error]bool{
+	gocql.ErrTimeoutNoResponse: true,
+	gocql.ErrTooManyTimeouts:   true,
+	gocql.ErrConnectionClosed:  true,
+	gocql.ErrNoStreams:         true,
+	gocql.ErrNoConnections:     true,
+}
+
+// unexecutedErrors are returned by gocql when the query was never sent
+var unexecutedErrors = map[error]bool{
+	gocql.ErrNoStreams:     true,
+	gocql.ErrNoConnections: true,
+}
+
+// retryableError returns true for transient errors of the cluster, i.e.
+// timeouts, unavailable replicas and overloaded hosts. Errors in the request
+// itself such as invalid syntax or missing permissions are fatal.
+func retryableError(err error) bool {
+	if retryableErrors[err] {
+		return true
+	}
+
+	switch requestError := err.(type) {
+	case *gocql.RequestErrUnavailable, *gocql.RequestErrReadTimeout, *gocql.RequestErrWriteTimeout:
+		return true
+	case gocql.RequestError:
+		return requestError.Code() == cqlErrorCodeOverloaded || requestError.Code() == cqlErrorCodeBootstrapping
+	}
+
+	return false
+}
+
+// unexecutedError returns true for errors after which the query is known not
+// to have been executed, i.e. it was not sent or the coordinator refused it
+// because replicas are unavailable or the coordinator is overloaded
+func unexecutedError(err error) bool {
+	if unexecutedErrors[err] {
+		return true
+	}
+
+	switch requestError := err.(type) {
+	case *gocql.RequestErrUnavailable:
+		return true
+	case gocql.RequestError:
+		return requestError.Code() == cqlErrorCodeOverloaded || requestError.Code() == cqlErrorCodeBootstrapping
+	}
+
+	return false
+}
+
+// RetryPolicy retries every query of the provider which fails with a
+// retryable error on the next host, up to Attempts times. Once every host has
+// been tried the hosts are tried again, see retryHostPolicy, so a cluster with
+// a single contact host is retried on that host.
+//
+// The first retry is made straight away and the ones after it wait with an
+// exponential backoff. The wait is made by retryHostPolicy when the next host
+// is picked, as gocql asks whether to attempt the query again before handing
+// over the error, and a fatal error is returned without waiting.
+//
+// Schema and role changes are not idempotent, a CREATE which timed out may
+// still have been applied and its retry would fail, so with NonIdempotent set
+// only the errors after which the statement was not executed are retried.
+type RetryPolicy struct {
+	Attempts      int
+	MinBackoff    time.Duration
+	MaxBackoff    time.Duration
+	NonIdempotent bool
+}
+
+// nonIdempotent returns a copy of the policy for statements which are not
+// idempotent
+func (policy *RetryPolicy) nonIdempotent() *RetryPolicy {
+	statementPolicy := *policy
+	statementPolicy.NonIdempotent = true
+
+	return &statementPolicy
+}
+
+// Attempt is called after a failed attempt, attempts counts the ones made so far
+func (policy *RetryPolicy) Attempt(query gocql.RetryableQuery) bool {
+	return query.Attempts() <= policy.Attempts
+}
+
+// GetRetryType retries retryable errors on the next host and returns any other
+func (policy *RetryPolicy) GetRetryType(err error) gocql.RetryType {
+	if policy.NonIdempotent && !unexecutedError(err) {
+		return gocql.Rethrow
+	}
+
+	if retryableError(err) {
+		return gocql.RetryNextHost
+	}
+
+	return gocql.Rethrow
+}
+
+// backoff doubles the minimum backoff for every retry, up to the maximum
+func (policy *RetryPolicy) backoff(retry int) time.Duration {
+	backoff := float64(policy.MinBackoff) * math.Pow(2, float64(retry-1))
+
+	if backoff > float64(policy.MaxBackoff) {
+		return policy.MaxBackoff
+	}
+
+	return time.Duration(backoff)
+}
+
+// wait sleeps before the given retry of a query, the first one is made
+// straight away
+func (policy *RetryPolicy) wait(retry int) {
+	if retry <= 1 {
+		return
+	}
+
+	backoff := policy.backoff(retry - 1)
+
+	log.Printf("[DEBUG] Retrying query, attempt %d of %d after %s", retry+1, policy.Attempts+1, backoff)
+
+	time.Sleep(backoff)
+}
+
+// retryHostPolicy wraps the host selection policy of the cluster. gocql gives
+// each host of the policy to a query only once, which would cap the retries
+// of RetryNextHost at the number of hosts less one, so the hosts are picked
+// again once they have all been tried.
+type retryHostPolicy struct {
+	gocql.HostSelectionPolicy
+
+	retryPolicy *RetryPolicy
+}
+
+// Pick returns the hosts of the wrapped policy followed by the same hosts
+// again, waiting for the backoff before each retry
+func (policy *retryHostPolicy) Pick(query gocql.ExecutableQuery) gocql.NextHost {
+	return retryHosts(policy.HostSelectionPolicy.Pick(query), query.Attempts, policy.retryPolicy.wait)
+}
+
+// retryHosts returns the hosts of next, then repeats the hosts it returned
+// until a full round of them passes without an attempt, i.e. every host is
+// down. gocql only asks for another host after an attempt when the query is
+// retried, in which case wait is called with the number of the retry.
+func retryHosts(next gocql.NextHost, attempts func() int, wait func(retry int)) gocql.NextHost {
+	var (
+		hosts        []gocql.SelectedHost
+		replayed     int
+		sinceAttempt int
+	)
+
+	initialAttempts := attempts()
+	lastAttempts := initialAttempts
+
+	return func() gocql.SelectedHost {
+		if current := attempts(); current > lastAttempts {
+			lastAttempts = current
+			sinceAttempt = 0
+
+			wait(current - initialAttempts)
+		}
+
+		if host := next(); host != nil {
+			hosts = append(hosts, host)
+			sinceAttempt++
+
+			return host
+		}
+
+		if len(hosts) == 0 || sinceAttempt >= len(hosts) {
+			return nil
+		}
+
+		host := hosts[replayed%len(hosts)]
+		replayed++
+		sinceAttempt++
+
+		return host
+	}
+}
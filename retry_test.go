@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// testRequestError is an error returned by the cluster with the given code
+type testRequestError int
+
+func (e testRequestError) Code() int       { return int(e) }
+func (e testRequestError) Message() string { return "request error" }
+func (e testRequestError) Error() string   { return "request error" }
+
+// testQuery is a query which has been attempted the given number of times
+type testQuery int
+
+func (q testQuery) Attempts() int                     { return int(q) }
+func (q testQuery) SetConsistency(gocql.Consistency)  {}
+func (q testQuery) GetConsistency() gocql.Consistency { return gocql.Quorum }
+func (q testQuery) Context() context.Context          { return context.Background() }
+
+// testHost is a host picked for a query
+type testHost string
+
+func (h testHost) Info() *gocql.HostInfo { return nil }
+func (h testHost) Mark(error)            {}
+
+func TestRetryPolicyGetRetryType(t *testing.T) {
+	cases := []struct {
+		err           error
+		retry         gocql.RetryType
+		nonIdempotent gocql.RetryType
+	}{
+		{gocql.ErrNoConnections, gocql.RetryNextHost, gocql.RetryNextHost},
+		{gocql.ErrNoStreams, gocql.RetryNextHost, gocql.RetryNextHost},
+		{&gocql.RequestErrUnavailable{}, gocql.RetryNextHost, gocql.RetryNextHost},
+		{testRequestError(cqlErrorCodeOverloaded), gocql.RetryNextHost, gocql.RetryNextHost},
+		{testRequestError(cqlErrorCodeBootstrapping), gocql.RetryNextHost, gocql.RetryNextHost},
+		{gocql.ErrTimeoutNoResponse, gocql.RetryNextHost, gocql.Rethrow},
+		{gocql.ErrConnectionClosed, gocql.RetryNextHost, gocql.Rethrow},
+		{&gocql.RequestErrReadTimeout{}, gocql.RetryNextHost, gocql.Rethrow},
+		{&gocql.RequestErrWriteTimeout{}, gocql.RetryNextHost, gocql.Rethrow},
+		{&gocql.RequestErrAlreadyExists{}, gocql.Rethrow, gocql.Rethrow},
+		{testRequestError(0x2000), gocql.Rethrow, gocql.Rethrow},
+		{errors.New("unauthorized"), gocql.Rethrow, gocql.Rethrow},
+	}
+
+	policy := &RetryPolicy{Attempts: 3}
+
+	for _, c := range cases {
+		if retry := policy.GetRetryType(c.err); retry != c.retry {
+			t.Errorf("%v: expected retry type %d, got %d", c.err, c.retry, retry)
+		}
+
+		if retry := policy.nonIdempotent().GetRetryType(c.err); retry != c.nonIdempotent {
+			t.Errorf("%v: expected retry type %d for a non idempotent statement, got %d", c.err, c.nonIdempotent, retry)
+		}
+	}
+}
+
+func TestRetryPolicyAttempt(t *testing.T) {
+	policy := &RetryPolicy{Attempts: 3}
+
+	for attempts := 1; attempts <= 3; attempts++ {
+		if !policy.Attempt(testQuery(attempts)) {
+			t.Errorf("expected a retry after %d attempts", attempts)
+		}
+	}
+
+	if policy.Attempt(testQuery(4)) {
+		t.Error("expected no retry after 4 attempts")
+	}
+
+	if (&RetryPolicy{}).Attempt(testQuery(1)) {
+		t.Error("expected no retry when retries are disabled")
+	}
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := &RetryPolicy{MinBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second}
+
+	for index, backoff := range expected {
+		if actual := policy.backoff(index + 1); actual != backoff {
+			t.Errorf("retry %d: expected backoff %s, got %s", index+1, backoff, actual)
+		}
+	}
+}
+
+// pickHosts returns the hosts picked for a query, which fails on every
+// attempt made on a host in up
+func pickHosts(hosts []testHost, up map[testHost]bool, attempts int) ([]testHost, []int) {
+	var (
+		picked  []testHost
+		retries []int
+	)
+
+	remaining := hosts
+
+	next := func() gocql.SelectedHost {
+		if len(remaining) == 0 {
+			return nil
+		}
+
+		host := remaining[0]
+		remaining = remaining[1:]
+
+		return host
+	}
+
+	made := 0
+
+	iter := retryHosts(next, func() int { return made }, func(retry int) { retries = append(retries, retry) })
+
+	for host := iter(); host != nil; host = iter() {
+		picked = append(picked, host.(testHost))
+
+		if !up[host.(testHost)] {
+			continue
+		}
+
+		made++
+
+		if made > attempts {
+			break
+		}
+	}
+
+	return picked, retries
+}
+
+func TestRetryHostsSingleHost(t *testing.T) {
+	picked, retries := pickHosts([]testHost{"a"}, map[testHost]bool{"a": true}, 3)
+
+	if !reflect.DeepEqual(picked, []testHost{"a", "a", "a", "a"}) {
+		t.Errorf("expected the single host to be retried, got %v", picked)
+	}
+
+	if !reflect.DeepEqual(retries, []int{1, 2, 3}) {
+		t.Errorf("expected a wait before each retry, got %v", retries)
+	}
+}
+
+func TestRetryHostsNextHostFirst(t *testing.T) {
+	picked, _ := pickHosts([]testHost{"a", "b"}, map[testHost]bool{"a": true, "b": true}, 3)
+
+	if !reflect.DeepEqual(picked, []testHost{"a", "b", "a", "b"}) {
+		t.Errorf("expected the hosts to be tried in turn, got %v", picked)
+	}
+}
+
+func TestRetryHostsDownHosts(t *testing.T) {
+	picked, retries := pickHosts([]testHost{"a", "b"}, map[testHost]bool{}, 3)
+
+	if !reflect.DeepEqual(picked, []testHost{"a", "b"}) {
+		t.Errorf("expected hosts which are down to be picked once, got %v", picked)
+	}
+
+	if len(retries) != 0 {
+		t.Errorf("expected no wait without an attempt, got %v", retries)
+	}
+
+	picked, _ = pickHosts([]testHost{"a", "b"}, map[testHost]bool{"b": true}, 2)
+
+	if !reflect.DeepEqual(picked, []testHost{"a", "b", "a", "b", "a", "b"}) {
+		t.Errorf("expected the host which is up to be retried, got %v", picked)
+	}
+}
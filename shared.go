@@ -3,6 +3,24 @@ package main
 import (
 	"crypto/sha256"
 	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/hashicorp/terraform-plugin-sdk/helper/schema"
+)
+
+const (
+	identifierConsistency = "consistency"
+
+	// system_auth is usually replicated to every datacenter, reading roles and
+	// permissions from a single replica can return data which is out of date
+	defaultAuthConsistency = gocql.LocalQuorum
+)
+
+var (
+	allowedConsistencies       = []string{"ANY", "ONE", "TWO", "THREE", "QUORUM", "ALL", "LOCAL_QUORUM", "EACH_QUORUM", "LOCAL_ONE"}
+	allowedSerialConsistencies = []string{"SERIAL", "LOCAL_SERIAL"}
 )
 
 // taken from here - http://techblog.d2-si.eu/2018/02/23/my-first-terraform-provider.html
@@ -10,3 +28,44 @@ func hash(s string) string {
 	sha := sha256.Sum256([]byte(s))
 	return hex.EncodeToString(sha[:])
 }
+
+func validConsistency(i interface{}, s string) (ws []string, errors []error) {
+	consistency := i.(string)
+
+	if _, err := gocql.ParseConsistencyWrapper(consistency); err != nil {
+		errors = append(errors, fmt.Errorf("%s: invalid value - must be one of %s", consistency, strings.Join(allowedConsistencies, ", ")))
+	}
+
+	return
+}
+
+func validSerialConsistency(i interface{}, s string) (ws []string, errors []error) {
+	var serialConsistency gocql.SerialConsistency
+
+	if err := serialConsistency.UnmarshalText([]byte(strings.ToUpper(i.(string)))); err != nil {
+		errors = append(errors, fmt.Errorf("%s: invalid value - must be one of %s", i.(string), strings.Join(allowedSerialConsistencies, ", ")))
+	}
+
+	return
+}
+
+// authConsistencySchema is the consistency override of the resources which
+// read roles and permissions from system_auth
+func authConsistencySchema() *schema.Schema {
+	return &schema.Schema{
+		Type:         schema.TypeString,
+		Optional:     true,
+		Description:  fmt.Sprintf("Consistency level used to read roles and permissions, defaults to %s - must be one of %s", defaultAuthConsistency, strings.Join(allowedConsistencies, ", ")),
+		ValidateFunc: validConsistency,
+	}
+}
+
+// authConsistency returns the consistency level the resource reads roles and
+// permissions with
+func authConsistency(d *schema.ResourceData) gocql.Consistency {
+	if consistency, err := gocql.ParseConsistencyWrapper(d.Get(identifierConsistency).(string)); err == nil {
+		return consistency
+	}
+
+	return defaultAuthConsistency
+}